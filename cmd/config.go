@@ -0,0 +1,24 @@
+package main
+
+import (
+	"log"
+	"music-library/config"
+	"os"
+)
+
+// configCommand implements "music-library config print [flags]", which prints the
+// effective configuration with secrets redacted.
+func configCommand(args []string) {
+	if len(args) == 0 || args[0] != "print" {
+		log.Fatal("Usage: music-library config print [flags]")
+	}
+
+	cfg, err := config.LoadConfig(args[1:])
+	if err != nil {
+		log.Fatal("Failed to load configuration:", err)
+	}
+
+	if err = cfg.Print(os.Stdout); err != nil {
+		log.Fatal("Failed to print configuration:", err)
+	}
+}
@@ -1,37 +1,90 @@
 package main
 
 import (
+	"context"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"log"
 	"music-library/config"
 	_ "music-library/docs"
 	"music-library/internal/db"
 	"music-library/internal/handlers"
+	"music-library/internal/metrics"
 	"music-library/internal/repositories"
 	"music-library/internal/services"
+	"music-library/internal/tracing"
 	"music-library/internal/utils"
+	"os"
+	"strings"
 )
 
 // @title Music library
 // @version 1.0
 
 func main() {
-	cfg, err := config.LoadConfig()
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "serve":
+		serve(args)
+	case "config":
+		configCommand(args)
+	case "migrate":
+		migrateCommand(args)
+	case "seed":
+		seedCommand(args)
+	default:
+		log.Fatalf("Unknown command %q, expected one of: serve, config, migrate, seed", command)
+	}
+}
+
+func serve(args []string) {
+	cfg, err := config.LoadConfig(args)
 	if err != nil {
 		log.Fatal("Failed to load configuration:", err)
 	}
+	if err = cfg.ValidateServe(); err != nil {
+		log.Fatal("Failed to load configuration:", err)
+	}
 
 	logger := utils.LoadLogger(cfg)
 	logger.Info("Logger initialized successfully")
 
-	if err = db.RunMigrations(cfg.PostgresURL(), logger); err != nil {
-		logger.Fatalf("Failed to run database migrations: %v", err)
+	shutdownTracing, err := tracing.Init(cfg, logger)
+	if err != nil {
+		logger.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	defer func() {
+		logger.Debug("Flushing traces")
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Errorf("Failed to shut down tracing: %v", err)
+		}
+	}()
+
+	if cfg.PostgresConnectTimeout > 0 {
+		if err = db.WaitForDB(context.Background(), cfg.PostgresURL(), cfg.PostgresConnectTimeout, logger); err != nil {
+			logger.Fatalf("Failed to reach the database: %v", err)
+		}
 	}
-	logger.Info("Database migrations run successfully")
 
-	pool, err := db.LoadDB(cfg.PostgresURL(), logger)
+	if cfg.AutoMigrate {
+		if err = db.RunMigrations(cfg.PostgresURL(), logger); err != nil {
+			logger.Fatalf("Failed to run database migrations: %v", err)
+		}
+		logger.Info("Database migrations run successfully")
+	} else {
+		logger.Info("Automatic migrations are disabled, run \"music-library migrate up\" to apply them")
+	}
+
+	pool, err := db.LoadDB(context.Background(), cfg.PostgresURL(), poolOptions(cfg, logger), logger)
 	if err != nil {
 		logger.Fatalf("Failed to connect to the database: %v", err)
 	}
@@ -41,37 +94,112 @@ func main() {
 		pool.Close()
 	}()
 
+	prometheus.MustRegister(metrics.NewPoolCollector(pool))
+
+	replicas, err := db.LoadReplicas(context.Background(), cfg.PostgresReplicaURLs(), poolOptions(cfg, logger), logger)
+	if err != nil {
+		logger.Fatalf("Failed to connect to the read replicas: %v", err)
+	}
+	defer replicas.Close()
+	go replicas.Monitor(context.Background(), cfg.ReplicaHealthInterval)
+
 	logger.Debug("Initializing external API client")
 	externalAPIClient := services.NewExternalAPIClient(cfg, logger)
 	logger.Debug("External API client initialized successfully")
 
 	logger.Debug("Initializing music library repository")
-	mlibRepo := repositories.NewMLibRepository(pool, logger)
+	mlibRepo := repositories.NewMLibRepository(pool, replicas, logger)
 	logger.Debug("Music library repository initialized successfully")
 
 	logger.Debug("Initializing music library service")
-	mlibService := services.NewMLibService(*mlibRepo, logger, externalAPIClient)
+	profanityFilter, err := utils.LoadProfanityFilter(cfg.ProfanityDir, cfg.ProfanityThreshold, logger)
+	if err != nil {
+		logger.Fatalf("Failed to load profanity word lists: %v", err)
+	}
+
+	mlibService := services.NewMLibService(*mlibRepo, logger, externalAPIClient, profanityFilter)
 	logger.Debug("Music library service initialized successfully")
 
+	go mlibService.PurgeIdempotencyKeys(context.Background(), cfg.IdempotencyPurgeInterval)
+	go mlibService.RefreshSimilarities(context.Background(), cfg.SimilarityRefreshInterval)
+
 	logger.Debug("Initializing handlers")
 	handler := handlers.NewMLibHandler(mlibService, logger)
 	logger.Debug("Handlers initialized successfully")
 
 	logger.Debug("Setting up router")
 	router := gin.Default()
+	router.Use(otelgin.Middleware(tracing.ServiceName))
+	router.Use(metrics.Middleware())
+	router.Use(handlers.RequestLogger(logger, cfg.LogDebugSampleRate))
+	router.Use(handlers.ReadYourWrites(cfg.ReadYourWritesWindow))
+	router.Use(handlers.Timeout(cfg.RequestTimeout, cfg.RouteTimeouts))
+	router.Use(handlers.MaxBodySize(cfg.MaxBodySize))
 
 	logger.Debug("Defining routes")
 
 	router.GET("/songs", handler.GetLibrary)
 	router.GET("/songs/:id", handler.GetText)
+	router.GET("/songs/:id/lyrics", handler.GetLyrics)
+	router.PUT("/songs/:id/lyrics", handler.PutLyrics)
+	router.GET("/songs/:id/lyrics/active", handler.GetActiveLine)
+	router.GET("/songs/:id/lyrics/diff", handler.GetLyricsDiff)
+	router.POST("/songs/:id/lyrics/diff", handler.DiffProposedLyrics)
+	router.GET("/songs/:id/history", handler.GetHistory)
+	router.GET("/songs/:id/verses/aligned", handler.GetAlignedVerses)
+	router.GET("/songs/:id/verses/search", handler.SearchVerses)
+	router.GET("/verses/search", handler.SearchLibraryVerses)
+	router.GET("/songs/:id/stats", handler.GetSongStats)
+	router.GET("/stats", handler.GetLibraryStats)
+	router.GET("/suggest", handlers.Timeout(cfg.SuggestTimeout, nil), handler.Suggest)
+	router.GET("/songs/:id/translations", handler.GetTranslations)
+	router.PUT("/songs/:id/translations/:lang", handler.PutTranslation)
+	router.DELETE("/songs/:id/translations/:lang", handler.DeleteTranslation)
+	router.GET("/songs/:id/similar", handler.GetSimilarSongs)
+	router.GET("/songs/:id/tags", handler.GetSongTags)
+	router.PUT("/songs/:id/tags/:tag", handler.AddSongTag)
+	router.DELETE("/songs/:id/tags/:tag", handler.RemoveSongTag)
+	router.GET("/tags", handler.GetTags)
+	router.DELETE("/tags/:tag", handler.DeleteTag)
+	router.PUT("/songs/:id/favorite", handler.AddFavorite)
+	router.DELETE("/songs/:id/favorite", handler.RemoveFavorite)
+	router.POST("/songs/:id/plays", handler.RecordPlay)
+	router.GET("/favorites", handler.GetFavorites)
+	router.GET("/plays", handler.GetPlays)
+	router.GET("/popular", handler.GetPopularSongs)
+	router.GET("/trending", handler.GetTrendingSongs)
+	router.GET("/playlists", handler.GetPlaylists)
+	router.POST("/playlists", handler.CreatePlaylist)
+	router.GET("/playlists/:id", handler.GetPlaylist)
+	router.PUT("/playlists/:id", handler.UpdatePlaylist)
+	router.DELETE("/playlists/:id", handler.DeletePlaylist)
+	router.POST("/playlists/:id/items", handler.AddPlaylistItem)
+	router.DELETE("/playlists/:id/items/:item", handler.RemovePlaylistItem)
+	router.PUT("/playlists/:id/items/:item/position", handler.MovePlaylistItem)
 	router.DELETE("/songs/:id", handler.DeleteSong)
 	router.PUT("/songs/:id", handler.EditSong)
-	router.POST("/songs", handler.AddSong)
+	router.POST("/songs", handler.Idempotency(cfg.IdempotencyTTL), handler.AddSong)
+	router.POST("/batch", handler.Batch)
 
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	logger.Info("API is listening on port %s", cfg.ServerPort)
+	logger.Infof("API is listening on port %s", cfg.ServerPort)
 	if err := router.Run(cfg.ServerAddress + cfg.ServerPort); err != nil {
 		logger.Fatalf("Could not start API: %v", err)
 	}
 }
+
+// poolOptions tunes the database pools as configured and prepares the repository's hot
+// statements on every new connection.
+func poolOptions(cfg *config.Config, logger *logrus.Logger) db.PoolOptions {
+	return db.PoolOptions{
+		MaxConns:          int32(cfg.PostgresMaxConns),
+		MinConns:          int32(cfg.PostgresMinConns),
+		MaxConnLifetime:   cfg.PostgresMaxConnLifetime,
+		MaxConnIdleTime:   cfg.PostgresMaxConnIdleTime,
+		HealthCheckPeriod: cfg.PostgresHealthCheckPeriod,
+		ExecMode:          db.ExecModes[cfg.PostgresExecMode],
+		AfterConnect:      repositories.PrepareStatements(logger),
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang-migrate/migrate/v4"
+	"log"
+	"music-library/config"
+	"music-library/internal/db"
+	"music-library/internal/utils"
+	"slices"
+	"strconv"
+)
+
+const migrateUsage = "Usage: music-library migrate up [N] | down N | goto V | version | force V [flags]"
+
+// migrateCommand implements "music-library migrate", which manages the schema with the
+// migrations embedded in the binary.
+func migrateCommand(args []string) {
+	if len(args) == 0 {
+		log.Fatal(migrateUsage)
+	}
+	action, args := args[0], args[1:]
+
+	// up takes an optional count, down, goto and force a required number.
+	var number *int
+	if len(args) > 0 {
+		if n, err := strconv.Atoi(args[0]); err == nil {
+			number, args = &n, args[1:]
+		}
+	}
+	if number == nil && (action == "down" || action == "goto" || action == "force") {
+		log.Fatal(migrateUsage)
+	}
+
+	if action == "goto" && *number < 0 {
+		log.Fatalf("Invalid version %d", *number)
+	}
+	if !slices.Contains([]string{"up", "down", "goto", "force", "version"}, action) {
+		log.Fatal(migrateUsage)
+	}
+
+	cfg, err := config.LoadConfig(args)
+	if err != nil {
+		log.Fatal("Failed to load configuration:", err)
+	}
+	logger := utils.LoadLogger(cfg)
+
+	migrator, err := db.NewMigrator(cfg.PostgresURL(), logger)
+	if err != nil {
+		logger.Fatalf("Failed to initialize migrations: %v", err)
+	}
+
+	// The migrator is closed before exiting, which skips deferred calls, so that its lock is
+	// released cleanly.
+	err = runMigrate(context.Background(), migrator, action, number)
+	if closeErr := migrator.Close(); closeErr != nil {
+		logger.Errorf("Failed to close migrations: %v", closeErr)
+	}
+	if err != nil {
+		logger.Fatal(err)
+	}
+}
+
+// runMigrate runs a migrate action and prints the resulting schema version.
+func runMigrate(ctx context.Context, migrator *db.Migrator, action string, number *int) error {
+	var err error
+	switch action {
+	case "up":
+		n := 0
+		if number != nil {
+			n = *number
+		}
+		err = migrator.Up(ctx, n)
+	case "down":
+		err = migrator.Down(ctx, *number)
+	case "goto":
+		err = migrator.Goto(ctx, uint(*number))
+	case "force":
+		err = migrator.Force(ctx, *number)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to run migrate %s: %w", action, err)
+	}
+
+	version, dirty, err := migrator.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		fmt.Println("version: none")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read migration version: %w", err)
+	}
+	fmt.Printf("version: %d, dirty: %t\n", version, dirty)
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"music-library/config"
+	"music-library/internal/db"
+	"music-library/internal/models"
+	"music-library/internal/repositories"
+	"music-library/internal/seed"
+	"music-library/internal/services"
+	"music-library/internal/utils"
+	"strconv"
+	"strings"
+)
+
+const seedUsage = "Usage: music-library seed list | <name> [N] [flags], N is the number of songs generated by the synthetic set"
+
+// defaultSyntheticSongs is the size of the synthetic seed set when no count is given.
+const defaultSyntheticSongs = 10000
+
+// seedCommand implements "music-library seed", which loads a named seed set into the library.
+// Songs already present are skipped, so a set can be loaded any number of times.
+func seedCommand(args []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		log.Fatal(seedUsage)
+	}
+	name, args := args[0], args[1:]
+
+	if name == "list" {
+		names, err := seed.Names()
+		if err != nil {
+			log.Fatal("Failed to list seed sets:", err)
+		}
+		fmt.Println(strings.Join(names, "\n"))
+		return
+	}
+
+	count := defaultSyntheticSongs
+	if len(args) > 0 {
+		if n, err := strconv.Atoi(args[0]); err == nil {
+			count, args = n, args[1:]
+		}
+	}
+
+	songs, err := seed.Load(name, count)
+	if err != nil {
+		log.Fatal("Failed to load seed set:", err)
+	}
+
+	cfg, err := config.LoadConfig(args)
+	if err != nil {
+		log.Fatal("Failed to load configuration:", err)
+	}
+	logger := utils.LoadLogger(cfg)
+
+	profanityFilter, err := utils.LoadProfanityFilter(cfg.ProfanityDir, cfg.ProfanityThreshold, logger)
+	if err != nil {
+		logger.Fatalf("Failed to load profanity word lists: %v", err)
+	}
+
+	pool, err := db.LoadDB(context.Background(), cfg.PostgresURL(), poolOptions(cfg, logger), logger)
+	if err != nil {
+		logger.Fatalf("Failed to connect to the database: %v", err)
+	}
+
+	mlibRepo := repositories.NewMLibRepository(pool, nil, logger)
+	mlibService := services.NewMLibService(*mlibRepo, logger, nil, profanityFilter)
+
+	// The pool is closed before exiting, which skips deferred calls, so that its connections
+	// are released cleanly.
+	err = runSeed(context.Background(), mlibService, name, songs)
+	pool.Close()
+	if err != nil {
+		logger.Fatal(err)
+	}
+}
+
+// runSeed loads songs, the seed set called name, and prints how many were added.
+func runSeed(ctx context.Context, mlibService *services.MLibService, name string, songs []models.Song) error {
+	added, skipped, err := mlibService.Seed(ctx, songs)
+	if err != nil {
+		return fmt.Errorf("failed to seed %s: %w", name, err)
+	}
+	fmt.Printf("seed %s: %d added, %d already present\n", name, added, skipped)
+	return nil
+}
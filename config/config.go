@@ -1,44 +1,338 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
+const defaultConfigFile = "./.env"
+
 type Config struct {
 	PostgresHost     string
 	PostgresPort     string
 	PostgresUser     string
 	PostgresPassword string
 	PostgresDB       string
+	PostgresSSLMode  string
+	PostgresParams   string
+	PostgresReplicas []string
+	PostgresExecMode string
+	AutoMigrate      bool
 	ServerAddress    string
 	ServerPort       string
 	LogLevel         string
+	LogFormat        string
 	ExternalAPI      string
+
+	RequestTimeout time.Duration
+	RouteTimeouts  map[string]time.Duration
+	MaxBodySize    int64
+	IdempotencyTTL time.Duration
+	SuggestTimeout time.Duration
+
+	IdempotencyPurgeInterval  time.Duration
+	SimilarityRefreshInterval time.Duration
+
+	ReplicaHealthInterval time.Duration
+	ReadYourWritesWindow  time.Duration
+
+	PostgresConnectTimeout    time.Duration
+	PostgresMaxConns          int
+	PostgresMinConns          int
+	PostgresMaxConnLifetime   time.Duration
+	PostgresMaxConnIdleTime   time.Duration
+	PostgresHealthCheckPeriod time.Duration
+
+	LogDebugSampleRate float64
+	LogMaxFieldLength  int
+
+	TracingExporter    string
+	TracingEndpoint    string
+	TracingFile        string
+	TracingSampleRatio float64
+
+	ProfanityDir       string
+	ProfanityThreshold int
+
+	// effective holds the resolved raw value of every setting for Print.
+	effective map[string]string
 }
 
-func LoadConfig() (*Config, error) {
-	viper.SetConfigFile("./.env")
-	err := viper.ReadInConfig()
+// LoadConfig resolves the configuration from defaults, an optional config file (.env, YAML or TOML),
+// environment variables and the command line flags in args, in that order of precedence.
+// The file is taken from --config or CONFIG_FILE and defaults to ./.env when it exists.
+// Secrets can be read from a file named by the <KEY>_FILE variable, e.g. POSTGRES_PASSWORD_FILE.
+func LoadConfig(args []string) (*Config, error) {
+	v := viper.New()
+
+	flags := pflag.NewFlagSet("music-library", pflag.ContinueOnError)
+	configFile := flags.String("config", "", "Path to a .env, YAML or TOML config file")
+	for _, s := range settings {
+		v.SetDefault(s.key, s.def)
+		flags.String(flagName(s.key), "", s.usage)
+		if err := v.BindPFlag(s.key, flags.Lookup(flagName(s.key))); err != nil {
+			return nil, fmt.Errorf("config: bind flag %s: %w", s.key, err)
+		}
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, fmt.Errorf("config: parse flags: %w", err)
+	}
+
+	v.AutomaticEnv()
+
+	path := *configFile
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path == "" {
+		if _, err := os.Stat(defaultConfigFile); err == nil {
+			path = defaultConfigFile
+		}
+	}
+	if path != "" {
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("config: read %s: %w", path, err)
+		}
+	}
+
+	effective := make(map[string]string, len(settings))
+	for _, s := range settings {
+		value, err := resolveSecret(v, s.key)
+		if err != nil {
+			return nil, err
+		}
+		effective[s.key] = value
+	}
+
+	requestTimeout, err := time.ParseDuration(effective["REQUEST_TIMEOUT"])
+	if err != nil {
+		return nil, fmt.Errorf("config: REQUEST_TIMEOUT: %w", err)
+	}
+	routeTimeouts, err := parseRouteTimeouts(effective["ROUTE_TIMEOUTS"])
 	if err != nil {
 		return nil, err
 	}
+	autoMigrate, err := strconv.ParseBool(effective["AUTO_MIGRATE"])
+	if err != nil {
+		return nil, fmt.Errorf("config: AUTO_MIGRATE: %w", err)
+	}
+	idempotencyTTL, err := time.ParseDuration(effective["IDEMPOTENCY_TTL"])
+	if err != nil {
+		return nil, fmt.Errorf("config: IDEMPOTENCY_TTL: %w", err)
+	}
+	idempotencyPurgeInterval, err := time.ParseDuration(effective["IDEMPOTENCY_PURGE_INTERVAL"])
+	if err != nil {
+		return nil, fmt.Errorf("config: IDEMPOTENCY_PURGE_INTERVAL: %w", err)
+	}
+	maxBodySize, err := strconv.ParseInt(effective["MAX_BODY_SIZE"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("config: MAX_BODY_SIZE: %w", err)
+	}
+	suggestTimeout, err := time.ParseDuration(effective["SUGGEST_TIMEOUT"])
+	if err != nil {
+		return nil, fmt.Errorf("config: SUGGEST_TIMEOUT: %w", err)
+	}
+	similarityRefreshInterval, err := time.ParseDuration(effective["SIMILARITY_REFRESH_INTERVAL"])
+	if err != nil {
+		return nil, fmt.Errorf("config: SIMILARITY_REFRESH_INTERVAL: %w", err)
+	}
+	replicaHealthInterval, err := time.ParseDuration(effective["REPLICA_HEALTH_INTERVAL"])
+	if err != nil {
+		return nil, fmt.Errorf("config: REPLICA_HEALTH_INTERVAL: %w", err)
+	}
+	readYourWritesWindow, err := time.ParseDuration(effective["READ_YOUR_WRITES_WINDOW"])
+	if err != nil {
+		return nil, fmt.Errorf("config: READ_YOUR_WRITES_WINDOW: %w", err)
+	}
+	postgresConnectTimeout, err := time.ParseDuration(effective["POSTGRES_CONNECT_TIMEOUT"])
+	if err != nil {
+		return nil, fmt.Errorf("config: POSTGRES_CONNECT_TIMEOUT: %w", err)
+	}
+	postgresMaxConns, err := strconv.Atoi(effective["POSTGRES_MAX_CONNS"])
+	if err != nil {
+		return nil, fmt.Errorf("config: POSTGRES_MAX_CONNS: %w", err)
+	}
+	postgresMinConns, err := strconv.Atoi(effective["POSTGRES_MIN_CONNS"])
+	if err != nil {
+		return nil, fmt.Errorf("config: POSTGRES_MIN_CONNS: %w", err)
+	}
+	postgresMaxConnLifetime, err := time.ParseDuration(effective["POSTGRES_MAX_CONN_LIFETIME"])
+	if err != nil {
+		return nil, fmt.Errorf("config: POSTGRES_MAX_CONN_LIFETIME: %w", err)
+	}
+	postgresMaxConnIdleTime, err := time.ParseDuration(effective["POSTGRES_MAX_CONN_IDLE_TIME"])
+	if err != nil {
+		return nil, fmt.Errorf("config: POSTGRES_MAX_CONN_IDLE_TIME: %w", err)
+	}
+	postgresHealthCheckPeriod, err := time.ParseDuration(effective["POSTGRES_HEALTH_CHECK_PERIOD"])
+	if err != nil {
+		return nil, fmt.Errorf("config: POSTGRES_HEALTH_CHECK_PERIOD: %w", err)
+	}
+	logDebugSampleRate, err := strconv.ParseFloat(effective["LOG_DEBUG_SAMPLE_RATE"], 64)
+	if err != nil {
+		return nil, fmt.Errorf("config: LOG_DEBUG_SAMPLE_RATE: %w", err)
+	}
+	logMaxFieldLength, err := strconv.Atoi(effective["LOG_MAX_FIELD_LENGTH"])
+	if err != nil {
+		return nil, fmt.Errorf("config: LOG_MAX_FIELD_LENGTH: %w", err)
+	}
+	tracingSampleRatio, err := strconv.ParseFloat(effective["TRACING_SAMPLE_RATIO"], 64)
+	if err != nil {
+		return nil, fmt.Errorf("config: TRACING_SAMPLE_RATIO: %w", err)
+	}
+	profanityThreshold, err := strconv.Atoi(effective["PROFANITY_THRESHOLD"])
+	if err != nil {
+		return nil, fmt.Errorf("config: PROFANITY_THRESHOLD: %w", err)
+	}
 
 	config := &Config{
-		PostgresHost:     viper.GetString("POSTGRES_HOST"),
-		PostgresPort:     viper.GetString("POSTGRES_PORT"),
-		PostgresUser:     viper.GetString("POSTGRES_USER"),
-		PostgresPassword: viper.GetString("POSTGRES_PASSWORD"),
-		PostgresDB:       viper.GetString("POSTGRES_DB"),
-		ServerAddress:    viper.GetString("SERVER_ADDRESS"),
-		ServerPort:       viper.GetString("SERVER_PORT"),
-		LogLevel:         viper.GetString("LOG_LEVEL"),
-		ExternalAPI:      viper.GetString("EXTERNAL_API"),
+		PostgresHost:     effective["POSTGRES_HOST"],
+		PostgresPort:     effective["POSTGRES_PORT"],
+		PostgresUser:     effective["POSTGRES_USER"],
+		PostgresPassword: effective["POSTGRES_PASSWORD"],
+		PostgresDB:       effective["POSTGRES_DB"],
+		PostgresSSLMode:  effective["POSTGRES_SSLMODE"],
+		PostgresParams:   effective["POSTGRES_PARAMS"],
+		PostgresReplicas: splitList(effective["POSTGRES_REPLICAS"]),
+		PostgresExecMode: effective["POSTGRES_EXEC_MODE"],
+		AutoMigrate:      autoMigrate,
+		ServerAddress:    effective["SERVER_ADDRESS"],
+		ServerPort:       effective["SERVER_PORT"],
+		LogLevel:         effective["LOG_LEVEL"],
+		LogFormat:        effective["LOG_FORMAT"],
+		ExternalAPI:      effective["EXTERNAL_API"],
+
+		RequestTimeout: requestTimeout,
+		RouteTimeouts:  routeTimeouts,
+		MaxBodySize:    maxBodySize,
+		IdempotencyTTL: idempotencyTTL,
+		SuggestTimeout: suggestTimeout,
+
+		IdempotencyPurgeInterval:  idempotencyPurgeInterval,
+		SimilarityRefreshInterval: similarityRefreshInterval,
+
+		ReplicaHealthInterval: replicaHealthInterval,
+		ReadYourWritesWindow:  readYourWritesWindow,
+
+		PostgresConnectTimeout:    postgresConnectTimeout,
+		PostgresMaxConns:          postgresMaxConns,
+		PostgresMinConns:          postgresMinConns,
+		PostgresMaxConnLifetime:   postgresMaxConnLifetime,
+		PostgresMaxConnIdleTime:   postgresMaxConnIdleTime,
+		PostgresHealthCheckPeriod: postgresHealthCheckPeriod,
+
+		LogDebugSampleRate: logDebugSampleRate,
+		LogMaxFieldLength:  logMaxFieldLength,
+
+		TracingExporter:    effective["TRACING_EXPORTER"],
+		TracingEndpoint:    effective["TRACING_ENDPOINT"],
+		TracingFile:        effective["TRACING_FILE"],
+		TracingSampleRatio: tracingSampleRatio,
+
+		ProfanityDir:       effective["PROFANITY_DIR"],
+		ProfanityThreshold: profanityThreshold,
+
+		effective: effective,
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
 	}
 	return config, nil
 }
 
+// resolveSecret returns the value of key, preferring the contents of the file named by <key>_FILE.
+func resolveSecret(v *viper.Viper, key string) (string, error) {
+	file := v.GetString(key + "_FILE")
+	if file == "" {
+		return v.GetString(key), nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("config: %s_FILE: %s does not exist", key, file)
+		}
+		return "", fmt.Errorf("config: %s_FILE: %w", key, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
 func (cfg *Config) PostgresURL() string {
-	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		cfg.PostgresUser, cfg.PostgresPassword, cfg.PostgresHost, cfg.PostgresPort, cfg.PostgresDB)
+	return cfg.postgresURL(net.JoinHostPort(cfg.PostgresHost, cfg.PostgresPort))
+}
+
+// PostgresReplicaURLs returns the connection string of every read replica. Replicas without
+// a port use POSTGRES_PORT.
+func (cfg *Config) PostgresReplicaURLs() []string {
+	urls := make([]string, 0, len(cfg.PostgresReplicas))
+	for _, replica := range cfg.PostgresReplicas {
+		host, port, err := net.SplitHostPort(replica)
+		if err != nil {
+			host, port = replica, cfg.PostgresPort
+		}
+		urls = append(urls, cfg.postgresURL(net.JoinHostPort(host, port)))
+	}
+	return urls
+}
+
+func (cfg *Config) postgresURL(hostPort string) string {
+	query, _ := url.ParseQuery(cfg.PostgresParams)
+	if query == nil {
+		query = url.Values{}
+	}
+	if cfg.PostgresSSLMode != "" {
+		query.Set("sslmode", cfg.PostgresSSLMode)
+	}
+
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.PostgresUser, cfg.PostgresPassword),
+		Host:     hostPort,
+		Path:     "/" + cfg.PostgresDB,
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
+
+// splitList splits a comma separated list, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseRouteTimeouts parses a comma separated list of "METHOD /route/template=duration" pairs,
+// for example "GET /songs=2s,POST /songs=15s".
+func parseRouteTimeouts(value string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		route, rawTimeout, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("config: route timeouts: missing '=' in %q", pair)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(rawTimeout))
+		if err != nil {
+			return nil, fmt.Errorf("config: route timeouts: %q: %w", pair, err)
+		}
+		timeouts[strings.Join(strings.Fields(route), " ")] = timeout
+	}
+	return timeouts, nil
 }
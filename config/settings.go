@@ -0,0 +1,65 @@
+package config
+
+import (
+	"strings"
+)
+
+// setting describes a single configuration key. Every key can be set, from lowest to highest
+// precedence, by its default, the config file, an environment variable of the same name
+// and a command line flag named after the key (POSTGRES_HOST becomes --postgres-host).
+type setting struct {
+	key    string
+	def    string
+	usage  string
+	secret bool
+}
+
+var settings = []setting{
+	{key: "POSTGRES_HOST", def: "localhost", usage: "PostgreSQL host"},
+	{key: "POSTGRES_PORT", def: "5432", usage: "PostgreSQL port"},
+	{key: "POSTGRES_USER", def: "postgres", usage: "PostgreSQL user"},
+	{key: "POSTGRES_PASSWORD", usage: "PostgreSQL password", secret: true},
+	{key: "POSTGRES_DB", def: "music_library", usage: "PostgreSQL database name"},
+	{key: "POSTGRES_SSLMODE", def: "disable", usage: "PostgreSQL sslmode (disable, allow, prefer, require, verify-ca, verify-full)"},
+	{key: "POSTGRES_PARAMS", usage: "Extra connection string parameters, e.g. connect_timeout=5&application_name=music-library"},
+	{key: "POSTGRES_CONNECT_TIMEOUT", def: "30s", usage: "How long startup waits for the database to become reachable"},
+	{key: "POSTGRES_MAX_CONNS", def: "10", usage: "Maximum number of connections in a pool"},
+	{key: "POSTGRES_MIN_CONNS", def: "0", usage: "Number of connections a pool keeps open when idle"},
+	{key: "POSTGRES_MAX_CONN_LIFETIME", def: "1h", usage: "Age after which a connection is closed"},
+	{key: "POSTGRES_MAX_CONN_IDLE_TIME", def: "30m", usage: "Idle time after which a connection is closed"},
+	{key: "POSTGRES_HEALTH_CHECK_PERIOD", def: "1m", usage: "How often idle connections are checked"},
+	{key: "POSTGRES_EXEC_MODE", def: "cache_statement", usage: "Statement cache mode (cache_statement, cache_describe, describe_exec, exec or simple_protocol)"},
+	{key: "POSTGRES_REPLICAS", usage: "Comma separated read replicas as host[:port], sharing the primary's user, database and parameters"},
+	{key: "REPLICA_HEALTH_INTERVAL", def: "5s", usage: "How often read replicas are health checked"},
+	{key: "READ_YOUR_WRITES_WINDOW", def: "5s", usage: "How long reads of a client go to the primary after it writes, 0 to disable"},
+	{key: "AUTO_MIGRATE", def: "true", usage: "Apply pending migrations when the server starts"},
+
+	{key: "SERVER_ADDRESS", def: "localhost", usage: "Address the API listens on"},
+	{key: "SERVER_PORT", def: ":8080", usage: "Port the API listens on"},
+	{key: "REQUEST_TIMEOUT", def: "10s", usage: "Default request deadline"},
+	{key: "MAX_BODY_SIZE", def: "1048576", usage: "Largest request body accepted, in bytes"},
+	{key: "ROUTE_TIMEOUTS", usage: "Per-route deadlines, e.g. \"GET /songs=2s,POST /songs=15s\""},
+	{key: "IDEMPOTENCY_TTL", def: "24h", usage: "How long responses to requests with an Idempotency-Key are replayed"},
+	{key: "IDEMPOTENCY_PURGE_INTERVAL", def: "1h", usage: "How often expired idempotency keys are deleted"},
+	{key: "SUGGEST_TIMEOUT", def: "300ms", usage: "Deadline of GET /suggest, shorter than the request deadline to keep typeahead fast"},
+	{key: "SIMILARITY_REFRESH_INTERVAL", def: "1m", usage: "How often similar songs of changed songs are recomputed"},
+
+	{key: "LOG_LEVEL", def: "info", usage: "Log level"},
+	{key: "LOG_FORMAT", def: "text", usage: "Log format (text or json)"},
+	{key: "LOG_DEBUG_SAMPLE_RATE", def: "0", usage: "Share of requests logged at debug level"},
+	{key: "LOG_MAX_FIELD_LENGTH", def: "200", usage: "Maximum length of a logged string field"},
+
+	{key: "EXTERNAL_API", usage: "Base URL of the song details API"},
+
+	{key: "TRACING_EXPORTER", def: "none", usage: "Trace exporter (none, otlp, stdout or file)"},
+	{key: "TRACING_ENDPOINT", usage: "OTLP/HTTP endpoint URL"},
+	{key: "TRACING_FILE", def: "./traces.json", usage: "File the file exporter writes to"},
+	{key: "TRACING_SAMPLE_RATIO", def: "1", usage: "Share of traces to sample"},
+
+	{key: "PROFANITY_DIR", usage: "Directory of per-language word lists named <language>.txt, replacing the built-in lists"},
+	{key: "PROFANITY_THRESHOLD", def: "1", usage: "Number of matched terms that flags lyrics as explicit"},
+}
+
+func flagName(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "_", "-"))
+}
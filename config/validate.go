@@ -0,0 +1,116 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	execModes        = []string{"cache_statement", "cache_describe", "describe_exec", "exec", "simple_protocol"}
+	sslModes         = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	logLevels        = []string{"panic", "fatal", "error", "warn", "warning", "info", "debug", "trace"}
+	logFormats       = []string{"text", "json"}
+	tracingExporters = []string{"none", "otlp", "stdout", "file"}
+)
+
+// Validate reports every invalid setting at once so that a bad deploy fails at startup.
+func (cfg *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(cfg.PostgresHost != "", "POSTGRES_HOST is required")
+	check(validPort(cfg.PostgresPort), "POSTGRES_PORT %q is not a valid port", cfg.PostgresPort)
+	check(cfg.PostgresUser != "", "POSTGRES_USER is required")
+	check(cfg.PostgresDB != "", "POSTGRES_DB is required")
+	check(slices.Contains(sslModes, cfg.PostgresSSLMode), "POSTGRES_SSLMODE %q must be one of %v", cfg.PostgresSSLMode, sslModes)
+	_, err := url.ParseQuery(cfg.PostgresParams)
+	check(err == nil, "POSTGRES_PARAMS %q is not a valid query string", cfg.PostgresParams)
+	for _, replica := range cfg.PostgresReplicas {
+		_, port, err := net.SplitHostPort(replica)
+		check(err != nil || validPort(port), "POSTGRES_REPLICAS: %q is not a valid host[:port]", replica)
+	}
+	check(cfg.PostgresConnectTimeout >= 0, "POSTGRES_CONNECT_TIMEOUT must not be negative")
+	check(cfg.PostgresMaxConns >= 1 && cfg.PostgresMaxConns <= math.MaxInt32, "POSTGRES_MAX_CONNS must be positive")
+	check(cfg.PostgresMinConns >= 0 && cfg.PostgresMinConns <= cfg.PostgresMaxConns, "POSTGRES_MIN_CONNS must be between 0 and POSTGRES_MAX_CONNS")
+	check(cfg.PostgresMaxConnLifetime > 0, "POSTGRES_MAX_CONN_LIFETIME must be positive")
+	check(cfg.PostgresMaxConnIdleTime > 0, "POSTGRES_MAX_CONN_IDLE_TIME must be positive")
+	check(cfg.PostgresHealthCheckPeriod > 0, "POSTGRES_HEALTH_CHECK_PERIOD must be positive")
+	check(slices.Contains(execModes, cfg.PostgresExecMode), "POSTGRES_EXEC_MODE %q must be one of %v", cfg.PostgresExecMode, execModes)
+	check(cfg.ReplicaHealthInterval > 0, "REPLICA_HEALTH_INTERVAL must be positive")
+	check(cfg.ReadYourWritesWindow >= 0, "READ_YOUR_WRITES_WINDOW must not be negative")
+
+	check(validPort(strings.TrimPrefix(cfg.ServerPort, ":")), "SERVER_PORT %q is not a valid port", cfg.ServerPort)
+	check(cfg.RequestTimeout >= 0, "REQUEST_TIMEOUT must not be negative")
+	for route, timeout := range cfg.RouteTimeouts {
+		check(timeout > 0, "ROUTE_TIMEOUTS: timeout for %q must be positive", route)
+	}
+	check(cfg.MaxBodySize > 0, "MAX_BODY_SIZE must be positive")
+	check(cfg.IdempotencyTTL > 0, "IDEMPOTENCY_TTL must be positive")
+	check(cfg.IdempotencyPurgeInterval > 0, "IDEMPOTENCY_PURGE_INTERVAL must be positive")
+	check(cfg.SuggestTimeout > 0, "SUGGEST_TIMEOUT must be positive")
+	check(cfg.SimilarityRefreshInterval > 0, "SIMILARITY_REFRESH_INTERVAL must be positive")
+
+	check(slices.Contains(logLevels, strings.ToLower(cfg.LogLevel)), "LOG_LEVEL %q must be one of %v", cfg.LogLevel, logLevels)
+	check(slices.Contains(logFormats, strings.ToLower(cfg.LogFormat)), "LOG_FORMAT %q must be one of %v", cfg.LogFormat, logFormats)
+	check(cfg.LogDebugSampleRate >= 0 && cfg.LogDebugSampleRate <= 1, "LOG_DEBUG_SAMPLE_RATE must be between 0 and 1")
+	check(cfg.LogMaxFieldLength >= 0, "LOG_MAX_FIELD_LENGTH must not be negative")
+
+	check(slices.Contains(tracingExporters, cfg.TracingExporter), "TRACING_EXPORTER %q must be one of %v", cfg.TracingExporter, tracingExporters)
+	check(cfg.TracingEndpoint == "" || validHTTPURL(cfg.TracingEndpoint), "TRACING_ENDPOINT %q is not a valid http(s) URL", cfg.TracingEndpoint)
+	check(cfg.TracingExporter != "file" || cfg.TracingFile != "", "TRACING_FILE is required for the file exporter")
+	check(cfg.TracingSampleRatio >= 0 && cfg.TracingSampleRatio <= 1, "TRACING_SAMPLE_RATIO must be between 0 and 1")
+
+	check(cfg.ProfanityThreshold >= 1, "PROFANITY_THRESHOLD must be at least 1")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("config: invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// ValidateServe reports the invalid settings only the API server uses, which the other commands
+// do not need to have set.
+func (cfg *Config) ValidateServe() error {
+	if !validHTTPURL(cfg.ExternalAPI) {
+		return fmt.Errorf("config: invalid configuration: EXTERNAL_API %q is not a valid http(s) URL", cfg.ExternalAPI)
+	}
+	return nil
+}
+
+// Print writes the effective configuration in .env format with secrets redacted.
+func (cfg *Config) Print(w io.Writer) error {
+	for _, s := range settings {
+		value := cfg.effective[s.key]
+		if s.secret && value != "" {
+			value = "******"
+		}
+		if strings.ContainsAny(value, " \t#\"") {
+			value = strconv.Quote(value)
+		}
+		if _, err := fmt.Fprintf(w, "%s=%s\n", s.key, value); err != nil {
+			return fmt.Errorf("config: print: %w", err)
+		}
+	}
+	return nil
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
+
+func validHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+	"music-library/migrations"
+)
+
+// migrationLock names the advisory lock held while migrations change the schema, so that
+// replicas starting together do not race.
+const migrationLock = "music-library/migrations"
+
+// Migrator applies the migrations embedded in the binary.
+type Migrator struct {
+	m          *migrate.Migrate
+	connString string
+	log        *logrus.Logger
+}
+
+func NewMigrator(connString string, log *logrus.Logger) (*Migrator, error) {
+	source, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("migrations: open embedded source: %w", err)
+	}
+	m, err := migrate.NewWithSourceInstance("iofs", source, connString)
+	if err != nil {
+		return nil, fmt.Errorf("migrations: init: %w", err)
+	}
+	return &Migrator{m: m, connString: connString, log: log}, nil
+}
+
+// Up applies the next n migrations, or all of them when n is 0.
+func (mg *Migrator) Up(ctx context.Context, n int) error {
+	return mg.locked(ctx, "up", func() error {
+		if n == 0 {
+			return mg.m.Up()
+		}
+		return mg.m.Steps(n)
+	})
+}
+
+// Down rolls back the last n migrations.
+func (mg *Migrator) Down(ctx context.Context, n int) error {
+	if n < 1 {
+		return fmt.Errorf("migrations: down: number of migrations must be positive, got %d", n)
+	}
+	return mg.locked(ctx, "down", func() error {
+		return mg.m.Steps(-n)
+	})
+}
+
+// Goto migrates up or down to the given version.
+func (mg *Migrator) Goto(ctx context.Context, version uint) error {
+	return mg.locked(ctx, "goto", func() error {
+		return mg.m.Migrate(version)
+	})
+}
+
+// Force sets the version without running migrations and clears the dirty flag,
+// to recover after a migration failed halfway. Version -1 means no migration.
+func (mg *Migrator) Force(ctx context.Context, version int) error {
+	return mg.locked(ctx, "force", func() error {
+		return mg.m.Force(version)
+	})
+}
+
+// Version returns the current version and whether the last migration failed halfway.
+// It returns migrate.ErrNilVersion when no migration has been applied.
+func (mg *Migrator) Version() (uint, bool, error) {
+	version, dirty, err := mg.m.Version()
+	if err != nil {
+		return 0, false, fmt.Errorf("migrations: version: %w", err)
+	}
+	return version, dirty, nil
+}
+
+func (mg *Migrator) Close() error {
+	sourceErr, dbErr := mg.m.Close()
+	return errors.Join(sourceErr, dbErr)
+}
+
+// locked runs a schema change while holding the migration advisory lock. A change with
+// nothing to do is not an error.
+func (mg *Migrator) locked(ctx context.Context, name string, change func() error) error {
+	// Parsed as a pool config so that pool_* parameters are not sent to the server.
+	poolConfig, err := pgxpool.ParseConfig(mg.connString)
+	if err != nil {
+		return fmt.Errorf("migrations: %s: parse config: %w", name, err)
+	}
+	conn, err := pgx.ConnectConfig(ctx, poolConfig.ConnConfig)
+	if err != nil {
+		return fmt.Errorf("migrations: %s: connect for lock: %w", name, err)
+	}
+	defer conn.Close(context.Background())
+
+	mg.log.Debug("Waiting for the migration lock")
+	if _, err = conn.Exec(ctx, "SELECT pg_advisory_lock(hashtext($1))", migrationLock); err != nil {
+		return fmt.Errorf("migrations: %s: lock: %w", name, err)
+	}
+	defer func() {
+		if _, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock(hashtext($1))", migrationLock); err != nil {
+			mg.log.Errorf("Failed to release the migration lock: %v", err)
+		}
+	}()
+
+	if err = change(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migrations: %s: %w", name, err)
+	}
+	return nil
+}
+
+// RunMigrations applies every pending migration.
+func RunMigrations(connString string, log *logrus.Logger) error {
+	log.Debug("Starting database migrations")
+	migrator, err := NewMigrator(connString, log)
+	if err != nil {
+		log.Errorf("Failed to initialize migrations: %v", err)
+		return err
+	}
+	defer migrator.Close()
+	log.Debug("Migrations initialized successfully")
+
+	if err = migrator.Up(context.Background(), 0); err != nil {
+		log.Errorf("Failed to apply migrations: %v", err)
+		return fmt.Errorf("postgres.go: run migrations: %w", err)
+	}
+	log.Info("Database migrations applied successfully")
+	return nil
+}
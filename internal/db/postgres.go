@@ -3,16 +3,69 @@ package db
 import (
 	"context"
 	"fmt"
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/exaring/otelpgx"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sirupsen/logrus"
+	"time"
 )
 
-func LoadDB(connString string, log *logrus.Logger) (*pgxpool.Pool, error) {
+const (
+	initialConnectBackoff = 250 * time.Millisecond
+	maxConnectBackoff     = 5 * time.Second
+)
+
+// PoolOptions tunes a connection pool. Zero values keep the pgxpool defaults.
+type PoolOptions struct {
+	MaxConns          int32
+	MinConns          int32
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
+	ExecMode          pgx.QueryExecMode
+
+	// AfterConnect is called on every new connection before it joins the pool.
+	AfterConnect func(context.Context, *pgx.Conn) error
+}
+
+// ExecModes names the query exec modes accepted in the configuration, as in pgx's
+// default_query_exec_mode connection parameter.
+var ExecModes = map[string]pgx.QueryExecMode{
+	"cache_statement": pgx.QueryExecModeCacheStatement,
+	"cache_describe":  pgx.QueryExecModeCacheDescribe,
+	"describe_exec":   pgx.QueryExecModeDescribeExec,
+	"exec":            pgx.QueryExecModeExec,
+	"simple_protocol": pgx.QueryExecModeSimpleProtocol,
+}
+
+// LoadDB creates a pool for connString. Connections are opened lazily, see WaitForDB.
+func LoadDB(ctx context.Context, connString string, opts PoolOptions, log *logrus.Logger) (*pgxpool.Pool, error) {
 	log.Debug("Attempting to connect to the database")
-	pool, err := pgxpool.New(context.Background(), connString)
+	poolConfig, err := pgxpool.ParseConfig(connString)
+	if err != nil {
+		log.Errorf("Failed to parse database connection string: %v", err)
+		return nil, fmt.Errorf("postgres.go: load db: parse config: %w", err)
+	}
+	poolConfig.ConnConfig.Tracer = otelpgx.NewTracer()
+	if opts.MaxConns > 0 {
+		poolConfig.MaxConns = opts.MaxConns
+	}
+	poolConfig.MinConns = opts.MinConns
+	if opts.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = opts.MaxConnLifetime
+	}
+	if opts.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = opts.MaxConnIdleTime
+	}
+	if opts.HealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = opts.HealthCheckPeriod
+	}
+	if opts.ExecMode != 0 {
+		poolConfig.ConnConfig.DefaultQueryExecMode = opts.ExecMode
+	}
+	poolConfig.AfterConnect = opts.AfterConnect
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		log.Errorf("Failed to connect to the database: %v", err)
 		return nil, err
@@ -22,21 +75,35 @@ func LoadDB(connString string, log *logrus.Logger) (*pgxpool.Pool, error) {
 	return pool, nil
 }
 
-func RunMigrations(connString string, log *logrus.Logger) error {
-	log.Debug("Starting database migrations")
-	m, err := migrate.New(
-		fmt.Sprintf("file://%s", "./migrations"),
-		connString)
+// WaitForDB connects to the database until it answers, doubling the pause between attempts,
+// so that the service can start before the database. It gives up after timeout.
+func WaitForDB(ctx context.Context, connString string, timeout time.Duration, log *logrus.Logger) error {
+	// Parsed as a pool config so that pool_* parameters are not sent to the server.
+	poolConfig, err := pgxpool.ParseConfig(connString)
 	if err != nil {
-		log.Errorf("Failed to initialize migrations: %v", err)
-		return err
+		return fmt.Errorf("postgres.go: wait for db: parse config: %w", err)
 	}
-	log.Debug("Migrations initialized successfully")
 
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		log.Errorf("Failed to apply migrations: %v", err)
-		return fmt.Errorf("postgres.go: run migrations: up: %w", err)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	backoff := initialConnectBackoff
+	for attempt := 1; ; attempt++ {
+		conn, err := pgx.ConnectConfig(ctx, poolConfig.ConnConfig)
+		if err == nil {
+			err = conn.Ping(ctx)
+			conn.Close(context.Background())
+		}
+		if err == nil {
+			return nil
+		}
+		log.Warnf("Database is not reachable (attempt %d), retrying in %s: %v", attempt, backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("postgres.go: wait for db: not reachable after %s: %w", timeout, err)
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxConnectBackoff)
 	}
-	log.Info("Database migrations applied successfully")
-	return nil
 }
@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNoHealthyReplica is returned by ReplicaSet.Acquire when no replica can serve a read.
+var ErrNoHealthyReplica = errors.New("no healthy replica")
+
+// ReplicaSet balances reads over read replica pools with round robin, skipping replicas that
+// failed their last health check or connection attempt. A nil *ReplicaSet has no replicas.
+type ReplicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+	log      *logrus.Logger
+}
+
+type replica struct {
+	name    string
+	pool    *pgxpool.Pool
+	healthy atomic.Bool
+}
+
+// LoadReplicas connects a pool to every replica. Replicas start healthy; pgxpool connects lazily,
+// so an unreachable replica is found by the first read or health check.
+func LoadReplicas(ctx context.Context, connStrings []string, opts PoolOptions, log *logrus.Logger) (*ReplicaSet, error) {
+	rs := &ReplicaSet{log: log}
+	for i, connString := range connStrings {
+		pool, err := LoadDB(ctx, connString, opts, log)
+		if err != nil {
+			rs.Close()
+			return nil, fmt.Errorf("replicas: replica %d: %w", i+1, err)
+		}
+		connConfig := pool.Config().ConnConfig
+		r := &replica{name: net.JoinHostPort(connConfig.Host, strconv.Itoa(int(connConfig.Port))), pool: pool}
+		r.healthy.Store(true)
+		rs.replicas = append(rs.replicas, r)
+	}
+	log.Infof("Loaded %d read replicas", len(rs.replicas))
+	return rs, nil
+}
+
+// Acquire returns a connection to the next healthy replica. A replica that fails to hand out a
+// connection is marked unhealthy and the next one is tried, until none is left.
+func (rs *ReplicaSet) Acquire(ctx context.Context) (*pgxpool.Conn, error) {
+	if rs == nil || len(rs.replicas) == 0 {
+		return nil, ErrNoHealthyReplica
+	}
+	start := rs.next.Add(1)
+	for i := range rs.replicas {
+		r := rs.replicas[(start+uint64(i))%uint64(len(rs.replicas))]
+		if !r.healthy.Load() {
+			continue
+		}
+		conn, err := r.pool.Acquire(ctx)
+		if err == nil {
+			return conn, nil
+		}
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("replicas: acquire: %w", err)
+		}
+		rs.log.Warnf("Read replica %s is unhealthy: %v", r.name, err)
+		r.healthy.Store(false)
+	}
+	return nil, ErrNoHealthyReplica
+}
+
+// Monitor pings every replica each interval and updates its health, until ctx is done.
+func (rs *ReplicaSet) Monitor(ctx context.Context, interval time.Duration) {
+	if rs == nil || len(rs.replicas) == 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, r := range rs.replicas {
+			pingCtx, cancel := context.WithTimeout(ctx, interval)
+			err := r.pool.Ping(pingCtx)
+			cancel()
+
+			healthy := err == nil
+			if r.healthy.Swap(healthy) != healthy {
+				if healthy {
+					rs.log.Infof("Read replica %s is healthy again", r.name)
+				} else {
+					rs.log.Warnf("Read replica %s is unhealthy: %v", r.name, err)
+				}
+			}
+		}
+	}
+}
+
+func (rs *ReplicaSet) Close() {
+	if rs == nil {
+		return
+	}
+	for _, r := range rs.replicas {
+		r.pool.Close()
+	}
+}
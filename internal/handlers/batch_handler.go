@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"music-library/internal/models"
+	"net/http"
+)
+
+// Batch godoc
+// @Summary      Run a batch of song operations
+// @Description  Runs add, edit and delete operations in one transaction and reports the result of each. In atomic mode (the default) any failure rolls back the whole batch; in best-effort mode failed operations are rolled back alone
+// @Tags         Songs
+// @Accept       json
+// @Produce      json
+// @Param        batch       body     models.BatchRequest true "Operations"
+// @Success      200         {object} models.BatchResponse
+// @Failure      400         {object} ErrorResponse
+// @Failure      404         {object} models.BatchResponse
+// @Failure      500         {object} models.BatchResponse
+// @Failure      504         {object} models.BatchResponse
+// @Router       /batch [post]
+func (h *MLibHandler) Batch(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering Batch handler")
+
+	var req models.BatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("Failed to bind JSON for batch: %v", err)
+		c.JSON(bindStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Debugf("Running batch of %d operations", len(req.Operations))
+	resp, err := h.Service.Batch(c.Request.Context(), req)
+	if err != nil {
+		log.Errorf("Batch failed: %v", err)
+		c.JSON(errorStatus(c, err), resp)
+		return
+	}
+
+	log.Info("Successfully ran batch")
+	c.JSON(http.StatusOK, resp)
+}
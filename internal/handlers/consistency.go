@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"music-library/internal/utils"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ReadYourWritesHeader asks for the reads of a request to go to the primary database.
+const ReadYourWritesHeader = "X-Read-Your-Writes"
+
+// ReadYourWrites sends the reads of a request to the primary database when the client asks for
+// it with the X-Read-Your-Writes header, or when the same client, identified by X-User-ID or its
+// IP address, made a successful write less than window ago. A zero window disables the latter.
+func ReadYourWrites(window time.Duration) gin.HandlerFunc {
+	writes := &recentWrites{window: window, last: make(map[string]time.Time)}
+	return func(c *gin.Context) {
+		client := c.ClientIP()
+		if user := utils.UserFromContext(c.Request.Context()); user != nil {
+			client = "user:" + *user
+		}
+
+		primary, _ := strconv.ParseBool(c.GetHeader(ReadYourWritesHeader))
+		if primary || writes.recent(client) {
+			c.Request = c.Request.WithContext(utils.ContextWithPrimary(c.Request.Context()))
+		}
+
+		c.Next()
+
+		if window > 0 && isWrite(c.Request.Method) && c.Writer.Status() < http.StatusBadRequest {
+			writes.record(client)
+		}
+	}
+}
+
+func isWrite(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
+
+// recentWrites remembers when each client last wrote, forgetting clients after window.
+type recentWrites struct {
+	window time.Duration
+
+	mu     sync.Mutex
+	last   map[string]time.Time
+	pruned time.Time
+}
+
+func (w *recentWrites) recent(client string) bool {
+	if w.window <= 0 {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	at, ok := w.last[client]
+	return ok && time.Since(at) < w.window
+}
+
+func (w *recentWrites) record(client string) {
+	now := time.Now()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.last[client] = now
+
+	if now.Sub(w.pruned) < w.window {
+		return
+	}
+	for c, at := range w.last {
+		if now.Sub(at) >= w.window {
+			delete(w.last, c)
+		}
+	}
+	w.pruned = now
+}
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// AddFavorite godoc
+// @Summary      Favorite a song
+// @Description  Adds a song to the favorites of the user making the request. Favoriting a song twice is not an error
+// @Tags         Engagement
+// @Accept       json
+// @Produce      json
+// @Param        X-User-ID   header   string  true  "User making the request"
+// @Param        id          path     int     true  "Song ID"
+// @Success      204
+// @Failure      400         {object} ErrorResponse
+// @Failure      401         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /songs/{id}/favorite [put]
+func (h *MLibHandler) AddFavorite(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering AddFavorite handler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		log.Warnf("Invalid song ID: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Debugf("Favoriting song ID %d", id)
+	if err = h.Service.AddFavorite(c.Request.Context(), id); err != nil {
+		log.Errorf("Failed to favorite song: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully favorited song")
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveFavorite godoc
+// @Summary      Unfavorite a song
+// @Description  Removes a song from the favorites of the user making the request
+// @Tags         Engagement
+// @Accept       json
+// @Produce      json
+// @Param        X-User-ID   header   string  true  "User making the request"
+// @Param        id          path     int     true  "Song ID"
+// @Success      204
+// @Failure      400         {object} ErrorResponse
+// @Failure      401         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /songs/{id}/favorite [delete]
+func (h *MLibHandler) RemoveFavorite(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering RemoveFavorite handler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		log.Warnf("Invalid song ID: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Debugf("Unfavoriting song ID %d", id)
+	if err = h.Service.RemoveFavorite(c.Request.Context(), id); err != nil {
+		log.Errorf("Failed to unfavorite song: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully unfavorited song")
+	c.Status(http.StatusNoContent)
+}
+
+// GetFavorites godoc
+// @Summary      List favorite songs
+// @Description  Lists the favorite songs of the user making the request, the most recently favorited first
+// @Tags         Engagement
+// @Accept       json
+// @Produce      json
+// @Param        X-User-ID   header   string  true  "User making the request"
+// @Param        page        query    int     false "Page number" default(1)
+// @Param        limit       query    int     false "Page size"   default(10)
+// @Success      200         {array}  models.Song
+// @Failure      400         {object} ErrorResponse
+// @Failure      401         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /favorites [get]
+func (h *MLibHandler) GetFavorites(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering GetFavorites handler")
+
+	page, limit, ok := h.pageParams(c)
+	if !ok {
+		return
+	}
+
+	songs, err := h.Service.GetFavorites(c.Request.Context(), page, limit)
+	if err != nil {
+		log.Errorf("Failed to get favorites: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Infof("Successfully fetched %d favorite songs", len(songs))
+	c.JSON(http.StatusOK, songs)
+}
+
+// RecordPlay godoc
+// @Summary      Record a play
+// @Description  Records a play of a song, in the listening history of the user making the request when known
+// @Tags         Engagement
+// @Accept       json
+// @Produce      json
+// @Param        X-User-ID   header   string  false "User making the request"
+// @Param        id          path     int     true  "Song ID"
+// @Success      204
+// @Failure      400         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /songs/{id}/plays [post]
+func (h *MLibHandler) RecordPlay(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering RecordPlay handler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		log.Warnf("Invalid song ID: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err = h.Service.RecordPlay(c.Request.Context(), id); err != nil {
+		log.Errorf("Failed to record play: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully recorded play")
+	c.Status(http.StatusNoContent)
+}
+
+// GetPlays godoc
+// @Summary      Get the listening history
+// @Description  Lists the plays of the user making the request, the latest first
+// @Tags         Engagement
+// @Accept       json
+// @Produce      json
+// @Param        X-User-ID   header   string  true  "User making the request"
+// @Param        page        query    int     false "Page number" default(1)
+// @Param        limit       query    int     false "Page size"   default(10)
+// @Success      200         {array}  models.Play
+// @Failure      400         {object} ErrorResponse
+// @Failure      401         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /plays [get]
+func (h *MLibHandler) GetPlays(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering GetPlays handler")
+
+	page, limit, ok := h.pageParams(c)
+	if !ok {
+		return
+	}
+
+	plays, err := h.Service.GetPlays(c.Request.Context(), page, limit)
+	if err != nil {
+		log.Errorf("Failed to get plays: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Infof("Successfully fetched %d plays", len(plays))
+	c.JSON(http.StatusOK, plays)
+}
+
+// GetPopularSongs godoc
+// @Summary      Get the most played songs
+// @Description  Lists the most played songs of all time, of the whole library or of one group
+// @Tags         Engagement
+// @Accept       json
+// @Produce      json
+// @Param        group       query    string  false "Exact name of the group"
+// @Param        top         query    int     false "Number of songs" default(10)
+// @Success      200         {array}  models.PopularSong
+// @Failure      400         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /popular [get]
+func (h *MLibHandler) GetPopularSongs(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering GetPopularSongs handler")
+
+	top, err := strconv.Atoi(c.DefaultQuery("top", "10"))
+	if err != nil {
+		log.Warnf("Invalid top parameter: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	var group *string
+	if name, ok := c.GetQuery("group"); ok {
+		group = &name
+	}
+
+	songs, err := h.Service.GetPopularSongs(c.Request.Context(), group, top)
+	if err != nil {
+		log.Errorf("Failed to get popular songs: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Infof("Successfully fetched %d popular songs", len(songs))
+	c.JSON(http.StatusOK, songs)
+}
+
+// GetTrendingSongs godoc
+// @Summary      Get trending songs
+// @Description  Lists the songs played the most in the last days, counted in UTC days including today
+// @Tags         Engagement
+// @Accept       json
+// @Produce      json
+// @Param        days        query    int     false "Length of the window in days" default(7)
+// @Param        top         query    int     false "Number of songs" default(10)
+// @Success      200         {array}  models.PopularSong
+// @Failure      400         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /trending [get]
+func (h *MLibHandler) GetTrendingSongs(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering GetTrendingSongs handler")
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if err != nil {
+		log.Warnf("Invalid days parameter: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	top, err := strconv.Atoi(c.DefaultQuery("top", "10"))
+	if err != nil {
+		log.Warnf("Invalid top parameter: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	songs, err := h.Service.GetTrendingSongs(c.Request.Context(), days, top)
+	if err != nil {
+		log.Errorf("Failed to get trending songs: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Infof("Successfully fetched %d trending songs", len(songs))
+	c.JSON(http.StatusOK, songs)
+}
+
+// pageParams reads the page and limit query parameters, writing a 400 response when either is
+// not a number.
+func (h *MLibHandler) pageParams(c *gin.Context) (page, limit int, ok bool) {
+	log := h.requestLogger(c)
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		log.Warnf("Invalid page parameter: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return 0, 0, false
+	}
+
+	limit, err = strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		log.Warnf("Invalid limit parameter: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return 0, 0, false
+	}
+	return page, limit, true
+}
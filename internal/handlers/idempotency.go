@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"io"
+	"music-library/internal/models"
+	"net/http"
+	"time"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+
+	// idempotencyClaimMargin is how long a key stays claimed after the request deadline, for the
+	// response to be stored.
+	idempotencyClaimMargin = 30 * time.Second
+)
+
+// responseRecorder keeps a copy of the response body written through it.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency makes a route safe to retry with an Idempotency-Key header. The first response for
+// a key is stored for ttl and replayed to later requests with the same key and body, server errors
+// excepted. A request made while another with the same key is being handled gets 409, and a key
+// reused with another body gets 422. The key is claimed for as long as the request may run, so
+// that a request that never finishes does not hold it for good.
+func (h *MLibHandler) Idempotency(ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		log := h.requestLogger(c).WithField("idempotency_key", key)
+
+		if len(key) > maxIdempotencyKeyLength {
+			log.Warn("Idempotency key is too long")
+			c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{Error: "Idempotency-Key must be at most 255 characters"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			log.Warnf("Failed to read request body: %v", err)
+			c.AbortWithStatusJSON(bindStatus(err), ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		lease := ttl
+		if deadline, ok := c.Request.Context().Deadline(); ok {
+			lease = time.Until(deadline) + idempotencyClaimMargin
+		}
+		stored, err := h.Service.ClaimIdempotencyKey(c.Request.Context(), key, requestHash, lease)
+		if err != nil {
+			log.Warnf("Idempotency check failed: %v", err)
+			c.AbortWithStatusJSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+			return
+		}
+		if stored != nil {
+			log.Info("Replaying stored response")
+			c.Header(IdempotentReplayedHeader, "true")
+			if stored.Location != "" {
+				c.Header("Location", stored.Location)
+			}
+			c.Data(stored.StatusCode, stored.ContentType, stored.Body)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		var response *models.IdempotentResponse
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError || status == StatusClientClosedRequest {
+			log.Infof("Not storing response with status %d", status)
+		} else {
+			response = &models.IdempotentResponse{
+				StatusCode:  status,
+				ContentType: recorder.Header().Get("Content-Type"),
+				Location:    recorder.Header().Get("Location"),
+				Body:        recorder.body.Bytes(),
+			}
+		}
+		if err = h.Service.FinishIdempotent(c.Request.Context(), key, requestHash, response, ttl); err != nil {
+			log.Errorf("Failed to store response for idempotency key: %v", err)
+		}
+	}
+}
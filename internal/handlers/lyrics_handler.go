@@ -0,0 +1,366 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+	"music-library/internal/models"
+	"music-library/internal/utils"
+	"net/http"
+	"strconv"
+)
+
+// GetLyrics godoc
+// @Summary      Get song lyrics
+// @Description  Fetches the lyrics of a song by ID split into ordered sections, as JSON, plain text or LRC
+// @Tags         Lyrics
+// @Accept       json
+// @Produce      json,plain
+// @Param        id          path     int     true  "Song ID"
+// @Param        format      query    string  false "Response format" Enums(json, txt, lrc) default(json)
+// @Param        lang        query    string  false "BCP-47 language of the lyrics, defaults to Accept-Language and then the original"
+// @Param        Accept-Language header string false "Preferred languages"
+// @Success      200         {object} models.Lyrics
+// @Failure      400         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /songs/{id}/lyrics [get]
+func (h *MLibHandler) GetLyrics(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering GetLyrics handler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		log.Warnf("Invalid song ID: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "txt" && format != "lrc" {
+		log.Warnf("Invalid lyrics format: %s", format)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "format must be one of json, txt, lrc"})
+		return
+	}
+
+	prefs, ok := h.languagePreferences(c)
+	if !ok {
+		return
+	}
+
+	log.Debugf("Fetching lyrics for song ID %d as %s", id, format)
+	lyrics, err := h.Service.GetLyrics(c.Request.Context(), id, prefs)
+	if err != nil {
+		log.Errorf("Failed to fetch lyrics: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully fetched lyrics for song")
+	c.Header("Content-Language", lyrics.Language)
+	switch format {
+	case "txt":
+		c.String(http.StatusOK, lyrics.Text())
+	case "lrc":
+		lrc, err := utils.FormatLRC(lyrics)
+		if err != nil {
+			log.Warnf("Failed to format lyrics as LRC: %v", err)
+			c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.String(http.StatusOK, lrc)
+	default:
+		c.JSON(http.StatusOK, lyrics)
+	}
+}
+
+// PutLyrics godoc
+// @Summary      Upload time-synced lyrics
+// @Description  Replaces the original lyrics of a song with an LRC or enhanced LRC document
+// @Tags         Lyrics
+// @Accept       plain
+// @Produce      json
+// @Param        id          path     int     true  "Song ID"
+// @Param        lyrics      body     string  true  "LRC document"
+// @Success      200         {object} models.Lyrics
+// @Failure      400         {object} LyricsValidationResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /songs/{id}/lyrics [put]
+func (h *MLibHandler) PutLyrics(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering PutLyrics handler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		log.Warnf("Invalid song ID: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		log.Warnf("Failed to read request body: %v", err)
+		c.JSON(bindStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Debugf("Uploading LRC lyrics for song ID %d", id)
+	lyrics, err := h.Service.SetSyncedLyrics(c.Request.Context(), id, string(body))
+	if err != nil {
+		var lrcErrs utils.LRCErrors
+		if errors.As(err, &lrcErrs) {
+			log.Warnf("Invalid LRC document: %v", err)
+			c.JSON(http.StatusBadRequest, LyricsValidationResponse{Error: "invalid LRC document", Details: lrcErrs})
+			return
+		}
+		log.Errorf("Failed to store lyrics: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully stored lyrics for song")
+	c.JSON(http.StatusOK, lyrics)
+}
+
+// GetActiveLine godoc
+// @Summary      Get the active lyrics line
+// @Description  Fetches the line of time-synced lyrics being sung at the given offset into the song
+// @Tags         Lyrics
+// @Accept       json
+// @Produce      json
+// @Param        id          path     int     true  "Song ID"
+// @Param        offset      query    int     true  "Offset into the song in milliseconds"
+// @Success      200         {object} models.ActiveLine
+// @Failure      400         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /songs/{id}/lyrics/active [get]
+func (h *MLibHandler) GetActiveLine(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering GetActiveLine handler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		log.Warnf("Invalid song ID: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	offset, err := strconv.Atoi(c.Query("offset"))
+	if err != nil || offset < 0 {
+		log.Warnf("Invalid offset parameter: %q", c.Query("offset"))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "offset must be a non-negative number of milliseconds"})
+		return
+	}
+
+	log.Debugf("Fetching active line for song ID %d at %d ms", id, offset)
+	active, err := h.Service.GetActiveLine(c.Request.Context(), id, offset)
+	if err != nil {
+		log.Errorf("Failed to fetch active line: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully fetched active line")
+	c.JSON(http.StatusOK, active)
+}
+
+// GetAlignedVerses godoc
+// @Summary      Get verses side by side
+// @Description  Fetches the verses of a song in two languages aligned by verse index, with pagination
+// @Tags         Lyrics
+// @Accept       json
+// @Produce      json
+// @Param        id          path     int     true  "Song ID"
+// @Param        with        query    string  true  "BCP-47 language shown next to the main language"
+// @Param        lang        query    string  false "BCP-47 main language, defaults to Accept-Language and then the original"
+// @Param        Accept-Language header string false "Preferred languages"
+// @Param        page        query    int     false "Page number" default(1)
+// @Param        limit       query    int     false "Page size"   default(10)
+// @Success      200         {array}  models.AlignedVerse
+// @Failure      400         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /songs/{id}/verses/aligned [get]
+func (h *MLibHandler) GetAlignedVerses(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering GetAlignedVerses handler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		log.Warnf("Invalid song ID: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	with := c.Query("with")
+	if with == "" {
+		log.Warn("Missing with parameter")
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "with is required"})
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		log.Warnf("Invalid page parameter: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		log.Warnf("Invalid limit parameter: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	prefs, ok := h.languagePreferences(c)
+	if !ok {
+		return
+	}
+
+	log.Debugf("Fetching verses for song ID %d aligned with %s", id, with)
+	verses, err := h.Service.GetAlignedVerses(c.Request.Context(), id, prefs, with, page, limit)
+	if err != nil {
+		log.Errorf("Failed to fetch aligned verses: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully fetched aligned verses")
+	c.JSON(http.StatusOK, verses)
+}
+
+// GetTranslations godoc
+// @Summary      List lyrics translations
+// @Description  Lists the languages the lyrics of a song are available in, the original first
+// @Tags         Lyrics
+// @Accept       json
+// @Produce      json
+// @Param        id          path     int     true  "Song ID"
+// @Success      200         {array}  models.Translation
+// @Failure      400         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /songs/{id}/translations [get]
+func (h *MLibHandler) GetTranslations(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering GetTranslations handler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		log.Warnf("Invalid song ID: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	translations, err := h.Service.GetTranslations(c.Request.Context(), id)
+	if err != nil {
+		log.Errorf("Failed to fetch translations: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully fetched translations")
+	c.JSON(http.StatusOK, translations)
+}
+
+// PutTranslation godoc
+// @Summary      Store lyrics in a language
+// @Description  Creates or replaces the lyrics of a song in a BCP-47 language, optionally marking them as the original
+// @Tags         Lyrics
+// @Accept       json
+// @Produce      json
+// @Param        id          path     int     true  "Song ID"
+// @Param        lang        path     string  true  "BCP-47 language"
+// @Param        lyrics      body     models.PutTranslation true "Lyrics"
+// @Success      200         {object} models.Lyrics
+// @Failure      400         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /songs/{id}/translations/{lang} [put]
+func (h *MLibHandler) PutTranslation(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering PutTranslation handler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		log.Warnf("Invalid song ID: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	var req models.PutTranslation
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("Failed to bind JSON for translation: %v", err)
+		c.JSON(bindStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Debugf("Storing %s lyrics for song ID %d", c.Param("lang"), id)
+	lyrics, err := h.Service.PutTranslation(c.Request.Context(), id, c.Param("lang"), req)
+	if err != nil {
+		log.Errorf("Failed to store translation: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully stored translation")
+	c.JSON(http.StatusOK, lyrics)
+}
+
+// DeleteTranslation godoc
+// @Summary      Delete a lyrics translation
+// @Description  Deletes the lyrics of a song in a language. The original lyrics cannot be deleted
+// @Tags         Lyrics
+// @Accept       json
+// @Produce      json
+// @Param        id          path     int     true  "Song ID"
+// @Param        lang        path     string  true  "BCP-47 language"
+// @Success      200         {object} SuccessResponse
+// @Failure      400         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
+// @Failure      409         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /songs/{id}/translations/{lang} [delete]
+func (h *MLibHandler) DeleteTranslation(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering DeleteTranslation handler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		log.Warnf("Invalid song ID: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Debugf("Deleting %s lyrics of song ID %d", c.Param("lang"), id)
+	err = h.Service.DeleteTranslation(c.Request.Context(), id, c.Param("lang"))
+	if err != nil {
+		log.Errorf("Failed to delete translation: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully deleted translation")
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Translation deleted successfully"})
+}
+
+// languagePreferences reads the preferred lyrics languages from the lang query parameter or the
+// Accept-Language header. It responds with 400 and returns false for an invalid lang parameter.
+func (h *MLibHandler) languagePreferences(c *gin.Context) ([]language.Tag, bool) {
+	prefs, err := utils.LanguagePreferences(c.Query("lang"), c.GetHeader("Accept-Language"))
+	if err != nil {
+		h.requestLogger(c).Warnf("Invalid lang parameter: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return nil, false
+	}
+	return prefs, true
+}
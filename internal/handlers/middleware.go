@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"math/rand/v2"
+	"music-library/internal/services"
+	"music-library/internal/utils"
+	"net/http"
+	"time"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+	UserIDHeader    = "X-User-ID"
+
+	// StatusClientClosedRequest is the non-standard status used when the client goes away before a response is written.
+	StatusClientClosedRequest = 499
+)
+
+// RequestLogger assigns every request an id and stores a child logger carrying the
+// request id, route and user in the request context. A debugSampleRate share of
+// requests logs at debug level regardless of the configured level.
+func RequestLogger(log *logrus.Logger, debugSampleRate float64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+		c.Header(RequestIDHeader, requestID)
+
+		base := log
+		if debugSampleRate > 0 && !log.IsLevelEnabled(logrus.DebugLevel) && rand.Float64() < debugSampleRate {
+			base = debugLogger(log)
+		}
+
+		fields := logrus.Fields{
+			"request_id": requestID,
+			"method":     c.Request.Method,
+			"route":      c.FullPath(),
+		}
+		ctx := c.Request.Context()
+		if user := c.GetHeader(UserIDHeader); user != "" {
+			fields["user"] = user
+			ctx = utils.ContextWithUser(ctx, user)
+		}
+		entry := base.WithFields(fields)
+
+		c.Request = c.Request.WithContext(utils.ContextWithLogger(ctx, entry))
+		c.Next()
+	}
+}
+
+// Timeout bounds the request context by the timeout configured for the route, keyed as "METHOD /route/template",
+// falling back to defaultTimeout. Cancellation reaches every database query and external call made with that context.
+func Timeout(defaultTimeout time.Duration, routeTimeouts map[string]time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout, ok := routeTimeouts[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			timeout = defaultTimeout
+		}
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// MaxBodySize rejects requests declaring a body of more than limit bytes with 413. Bodies of
+// unknown length are cut off at the limit, which fails reading them.
+func MaxBodySize(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > limit {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, ErrorResponse{Error: fmt.Sprintf("request body must be at most %d bytes", limit)})
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
+
+// bindStatus maps an error reading or binding the request body to a response status: 413 when
+// the body is over the size limit and 400 otherwise.
+func bindStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// errorStatus maps a service error to a response status: 400 for invalid input, 401 for an
+// unknown user, 403 for changes to what another user owns, 404 for missing resources and pages,
+// 409 for conflicts, 422 for reused idempotency keys and lyrics too long to compare, 504 when
+// the route deadline was exceeded, 499 when the client canceled the request and 500 otherwise.
+func errorStatus(c *gin.Context, err error) int {
+	ctxErr := c.Request.Context().Err()
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, services.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrNotFound) || errors.Is(err, utils.ErrLyricsNotSynced) || errors.Is(err, utils.ErrPageOutOfRange):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrIdempotencyKeyReused) || errors.Is(err, utils.ErrDiffTooLarge):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctxErr, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled) || errors.Is(ctxErr, context.Canceled):
+		return StatusClientClosedRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// requestLogger returns the request-scoped logger set up by RequestLogger.
+func (h *MLibHandler) requestLogger(c *gin.Context) *logrus.Entry {
+	return utils.LoggerFromContext(c.Request.Context(), h.log)
+}
+
+func debugLogger(log *logrus.Logger) *logrus.Logger {
+	return &logrus.Logger{
+		Out:          log.Out,
+		Hooks:        log.Hooks,
+		Formatter:    log.Formatter,
+		ReportCaller: log.ReportCaller,
+		Level:        logrus.DebugLevel,
+		ExitFunc:     log.ExitFunc,
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = cryptorand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"music-library/internal/models"
 	"music-library/internal/services"
+	"music-library/internal/utils"
 	"net/http"
 	"strconv"
 )
@@ -17,6 +18,11 @@ type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
+type LyricsValidationResponse struct {
+	Error   string           `json:"error"`
+	Details []utils.LRCError `json:"details"`
+}
+
 type MLibHandler struct {
 	Service *services.MLibService
 	log     *logrus.Logger
@@ -38,53 +44,65 @@ func NewMLibHandler(service *services.MLibService, log *logrus.Logger) *MLibHand
 // @Param        releaseDate query    string false "Filter by release date"
 // @Param        text      	 query    string false "Filter by text"
 // @Param        link      	 query    string false "Filter by link"
+// @Param        excludeExplicit query bool  false "Leave out songs flagged as explicit"
+// @Param        tags        query    string false "Comma separated tags"
+// @Param        tagMatch    query    string false "Match any or all of the tags" Enums(any, all) default(any)
+// @Param        sort        query    string false "Order of the songs, popular for the most played first" Enums(id, popular) default(id)
 // @Param        page      	 query    int    false "Page number" default(1)
 // @Param        limit       query    int    false "Page size" default(10)
 // @Success      200         {array}  models.Song
 // @Failure      400         {object} ErrorResponse
 // @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
 // @Router       /songs [get]
 func (h *MLibHandler) GetLibrary(c *gin.Context) {
-	h.log.Debug("Entering GetLibrary handler")
+	log := h.requestLogger(c)
+	log.Debug("Entering GetLibrary handler")
 
 	var filter models.LibraryFilter
 	if err := c.ShouldBindQuery(&filter); err != nil {
-		h.log.Warnf("Failed to bind query parameters: %v", err)
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		log.Warnf("Failed to bind query parameters: %v", err)
+		c.JSON(bindStatus(err), ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	h.log.Debugf("Fetching library with filter: %+v", filter)
-	songs, err := h.Service.GetLibrary(filter)
+	log.Debugf("Fetching library with filter: %+v", filter)
+	songs, err := h.Service.GetLibrary(c.Request.Context(), filter)
 	if err != nil {
-		h.log.Errorf("Failed to fetch library: %v", err)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		log.Errorf("Failed to fetch library: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	h.log.Info("Successfully fetched library")
+	log.Info("Successfully fetched library")
 	c.JSON(http.StatusOK, songs)
 }
 
 // GetText godoc
 // @Summary      Get song text
-// @Description  Fetches the text of a song by ID with pagination
+// @Description  Fetches the text of a song by ID with pagination. Page 1 of empty lyrics is empty and pages past the verses are not found
 // @Tags         Songs
 // @Accept       json
 // @Produce      json
 // @Param        id          path     int     true  "Song ID"
 // @Param        page        query    int     false "Page number" default(1)
 // @Param        limit       query    int     false "Page size"   default(10)
+// @Param        censor      query    bool    false "Mask explicit words" default(false)
+// @Param        lang        query    string  false "BCP-47 language of the lyrics, defaults to Accept-Language and then the original"
+// @Param        Accept-Language header string false "Preferred languages"
 // @Success      200         {array}  string
 // @Failure      400         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
 // @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
 // @Router       /songs/{id} [get]
 func (h *MLibHandler) GetText(c *gin.Context) {
-	h.log.Debug("Entering GetText handler")
+	log := h.requestLogger(c)
+	log.Debug("Entering GetText handler")
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		h.log.Warnf("Invalid song ID: %v", err)
+		log.Warnf("Invalid song ID: %v", err)
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
@@ -92,7 +110,7 @@ func (h *MLibHandler) GetText(c *gin.Context) {
 	pageStr := c.DefaultQuery("page", "1")
 	page, err := strconv.Atoi(pageStr)
 	if err != nil {
-		h.log.Warnf("Invalid page parameter: %v", err)
+		log.Warnf("Invalid page parameter: %v", err)
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
@@ -100,20 +118,33 @@ func (h *MLibHandler) GetText(c *gin.Context) {
 	limitStr := c.DefaultQuery("limit", "10")
 	limit, err := strconv.Atoi(limitStr)
 	if err != nil {
-		h.log.Warnf("Invalid limit parameter: %v", err)
+		log.Warnf("Invalid limit parameter: %v", err)
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	h.log.Debugf("Fetching text for song ID %d with page %d and limit %d", id, page, limit)
-	verses, err := h.Service.GetText(id, page, limit)
+	censor, err := strconv.ParseBool(c.DefaultQuery("censor", "false"))
 	if err != nil {
-		h.log.Errorf("Failed to fetch text: %v", err)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		log.Warnf("Invalid censor parameter: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	prefs, ok := h.languagePreferences(c)
+	if !ok {
 		return
 	}
 
-	h.log.Info("Successfully fetched text for song")
+	log.Debugf("Fetching text for song ID %d with page %d and limit %d", id, page, limit)
+	verses, lang, err := h.Service.GetText(c.Request.Context(), id, page, limit, prefs, censor)
+	if err != nil {
+		log.Errorf("Failed to fetch text: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully fetched text for song")
+	c.Header("Content-Language", lang)
 	c.JSON(http.StatusOK, verses)
 }
 
@@ -124,104 +155,118 @@ func (h *MLibHandler) GetText(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        id          path     int     true  "Song ID"
-// @Success      200         {object} SuccessResponse
+// @Success      204
 // @Failure      400         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
 // @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
 // @Router       /songs/{id} [delete]
 func (h *MLibHandler) DeleteSong(c *gin.Context) {
-	h.log.Debug("Entering DeleteSong handler")
+	log := h.requestLogger(c)
+	log.Debug("Entering DeleteSong handler")
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		h.log.Warnf("Invalid song ID: %v", err)
+		log.Warnf("Invalid song ID: %v", err)
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	h.log.Debugf("Attempting to delete song with ID %d", id)
-	err = h.Service.DeleteSong(id)
+	log.Debugf("Attempting to delete song with ID %d", id)
+	err = h.Service.DeleteSong(c.Request.Context(), id)
 	if err != nil {
-		h.log.Errorf("Failed to delete song: %v", err)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		log.Errorf("Failed to delete song: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	h.log.Info("Successfully deleted song")
-	c.JSON(http.StatusOK, SuccessResponse{Message: "Song deleted successfully"})
+	log.Info("Successfully deleted song")
+	c.Status(http.StatusNoContent)
 }
 
 // EditSong godoc
 // @Summary      Edit a song
-// @Description  Edits a song's details by ID
+// @Description  Edits a song's details by ID and returns the edited song
 // @Tags         Songs
 // @Accept       json
 // @Produce      json
 // @Param        id          path     int       true  "Song ID"
 // @Param        song        body     models.EditSong true "Updated song data"
-// @Success      200         {object} SuccessResponse
+// @Success      200         {object} models.Song
 // @Failure      400         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
 // @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
 // @Router       /songs/{id} [put]
 func (h *MLibHandler) EditSong(c *gin.Context) {
-	h.log.Debug("Entering EditSong handler")
+	log := h.requestLogger(c)
+	log.Debug("Entering EditSong handler")
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		h.log.Warnf("Invalid song ID: %v", err)
+		log.Warnf("Invalid song ID: %v", err)
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
 	var editSong models.EditSong
 	if err := c.ShouldBindJSON(&editSong); err != nil {
-		h.log.Warnf("Failed to bind JSON for edit request: %v", err)
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		log.Warnf("Failed to bind JSON for edit request: %v", err)
+		c.JSON(bindStatus(err), ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	h.log.Debugf("Editing song with ID %d: %+v", id, editSong)
-	err = h.Service.EditSong(id, editSong)
+	log.Debugf("Editing song with ID %d", id)
+	song, err := h.Service.EditSong(c.Request.Context(), id, editSong)
 	if err != nil {
-		h.log.Errorf("Failed to edit song: %v", err)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		log.Errorf("Failed to edit song: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	h.log.Info("Successfully edited song")
-	c.JSON(http.StatusOK, SuccessResponse{Message: "Song edited successfully"})
+	log.Info("Successfully edited song")
+	c.JSON(http.StatusOK, &song)
 }
 
 // AddSong godoc
 // @Summary      Add a new song
-// @Description  Adds a new song to the library
+// @Description  Adds a new song to the library and returns it with a Location header. Retries with the same Idempotency-Key replay the first response instead of adding the song again, and a retry made while the first request is still being handled gets 409
 // @Tags         Songs
 // @Accept       json
 // @Produce      json
 // @Param        song        body     models.AddSong true "New song data"
-// @Success      200         {object} SuccessResponse
+// @Param        Idempotency-Key header string false "Key making retries of this request safe"
+// @Success      201         {object} models.Song
+// @Header       201         {string} Location "/songs/{id}"
 // @Failure      400         {object} ErrorResponse
+// @Failure      409         {object} ErrorResponse
+// @Failure      413         {object} ErrorResponse
+// @Failure      422         {object} ErrorResponse
 // @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
 // @Router       /songs [post]
 func (h *MLibHandler) AddSong(c *gin.Context) {
-	h.log.Debug("Entering AddSong handler")
+	log := h.requestLogger(c)
+	log.Debug("Entering AddSong handler")
 
 	var addSong models.AddSong
 	if err := c.ShouldBindJSON(&addSong); err != nil {
-		h.log.Warnf("Failed to bind JSON for new song: %v", err)
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		log.Warnf("Failed to bind JSON for new song: %v", err)
+		c.JSON(bindStatus(err), ErrorResponse{Error: err.Error()})
 		return
 	}
 
 	song := models.Song{Group: addSong.Group, Song: addSong.Song}
 
-	h.log.Debugf("Adding new song: %+v", song)
-	err := h.Service.AddSong(song)
+	log.WithFields(utils.SongFields(song)).Debug("Adding new song")
+	added, err := h.Service.AddSong(c.Request.Context(), song)
 	if err != nil {
-		h.log.Errorf("Failed to add song: %v", err)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		log.Errorf("Failed to add song: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	h.log.Info("Successfully added new song")
-	c.JSON(http.StatusOK, SuccessResponse{Message: "Song added successfully"})
+	log.Info("Successfully added new song")
+	c.Header("Location", "/songs/"+strconv.Itoa(*added.ID))
+	c.JSON(http.StatusCreated, &added)
 }
@@ -0,0 +1,349 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"music-library/internal/models"
+	"net/http"
+	"strconv"
+)
+
+// CreatePlaylist godoc
+// @Summary      Create a playlist
+// @Description  Creates an empty playlist owned by the user making the request and returns it with a Location header
+// @Tags         Playlists
+// @Accept       json
+// @Produce      json
+// @Param        X-User-ID   header   string  true  "User making the request"
+// @Param        playlist    body     models.PlaylistInput true "Name and description"
+// @Success      201         {object} models.Playlist
+// @Header       201         {string} Location "/playlists/{id}"
+// @Failure      400         {object} ErrorResponse
+// @Failure      401         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /playlists [post]
+func (h *MLibHandler) CreatePlaylist(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering CreatePlaylist handler")
+
+	var input models.PlaylistInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Warnf("Failed to bind JSON for new playlist: %v", err)
+		c.JSON(bindStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	playlist, err := h.Service.CreatePlaylist(c.Request.Context(), input)
+	if err != nil {
+		log.Errorf("Failed to create playlist: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully created playlist")
+	c.Header("Location", "/playlists/"+strconv.Itoa(playlist.ID))
+	c.JSON(http.StatusCreated, playlist)
+}
+
+// GetPlaylists godoc
+// @Summary      List playlists
+// @Description  Lists the playlists of the user making the request, the most recently changed first
+// @Tags         Playlists
+// @Accept       json
+// @Produce      json
+// @Param        X-User-ID   header   string  true  "User making the request"
+// @Success      200         {array}  models.Playlist
+// @Failure      401         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /playlists [get]
+func (h *MLibHandler) GetPlaylists(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering GetPlaylists handler")
+
+	playlists, err := h.Service.GetPlaylists(c.Request.Context())
+	if err != nil {
+		log.Errorf("Failed to get playlists: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Infof("Successfully fetched %d playlists", len(playlists))
+	c.JSON(http.StatusOK, playlists)
+}
+
+// GetPlaylist godoc
+// @Summary      Get a playlist
+// @Description  Gets a playlist with a page of its items in order. Items whose song was deleted are listed as unavailable
+// @Tags         Playlists
+// @Accept       json
+// @Produce      json
+// @Param        id          path     int     true  "Playlist ID"
+// @Param        page        query    int     false "Page number" default(1)
+// @Param        limit       query    int     false "Page size"   default(10)
+// @Success      200         {object} models.PlaylistPage
+// @Failure      400         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /playlists/{id} [get]
+func (h *MLibHandler) GetPlaylist(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering GetPlaylist handler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		log.Warnf("Invalid playlist ID: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	page, limit, ok := h.pageParams(c)
+	if !ok {
+		return
+	}
+
+	log.Debugf("Fetching playlist ID %d with page %d and limit %d", id, page, limit)
+	playlist, err := h.Service.GetPlaylist(c.Request.Context(), id, page, limit)
+	if err != nil {
+		log.Errorf("Failed to get playlist: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully fetched playlist")
+	c.JSON(http.StatusOK, playlist)
+}
+
+// UpdatePlaylist godoc
+// @Summary      Edit a playlist
+// @Description  Replaces the name and description of a playlist of the user making the request
+// @Tags         Playlists
+// @Accept       json
+// @Produce      json
+// @Param        X-User-ID   header   string  true  "User making the request"
+// @Param        id          path     int     true  "Playlist ID"
+// @Param        playlist    body     models.PlaylistInput true "Name and description"
+// @Success      200         {object} models.Playlist
+// @Failure      400         {object} ErrorResponse
+// @Failure      401         {object} ErrorResponse
+// @Failure      403         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /playlists/{id} [put]
+func (h *MLibHandler) UpdatePlaylist(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering UpdatePlaylist handler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		log.Warnf("Invalid playlist ID: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	var input models.PlaylistInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Warnf("Failed to bind JSON for playlist edit: %v", err)
+		c.JSON(bindStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Debugf("Editing playlist ID %d", id)
+	playlist, err := h.Service.UpdatePlaylist(c.Request.Context(), id, input)
+	if err != nil {
+		log.Errorf("Failed to edit playlist: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully edited playlist")
+	c.JSON(http.StatusOK, playlist)
+}
+
+// DeletePlaylist godoc
+// @Summary      Delete a playlist
+// @Description  Deletes a playlist of the user making the request
+// @Tags         Playlists
+// @Accept       json
+// @Produce      json
+// @Param        X-User-ID   header   string  true  "User making the request"
+// @Param        id          path     int     true  "Playlist ID"
+// @Success      204
+// @Failure      400         {object} ErrorResponse
+// @Failure      401         {object} ErrorResponse
+// @Failure      403         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /playlists/{id} [delete]
+func (h *MLibHandler) DeletePlaylist(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering DeletePlaylist handler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		log.Warnf("Invalid playlist ID: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Debugf("Deleting playlist ID %d", id)
+	if err = h.Service.DeletePlaylist(c.Request.Context(), id); err != nil {
+		log.Errorf("Failed to delete playlist: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully deleted playlist")
+	c.Status(http.StatusNoContent)
+}
+
+// AddPlaylistItem godoc
+// @Summary      Add a song to a playlist
+// @Description  Adds a song to a playlist of the user making the request at a position, shifting the following items down, or at the end
+// @Tags         Playlists
+// @Accept       json
+// @Produce      json
+// @Param        X-User-ID   header   string  true  "User making the request"
+// @Param        id          path     int     true  "Playlist ID"
+// @Param        item        body     models.AddPlaylistItem true "Song and position"
+// @Success      201         {object} models.PlaylistItem
+// @Failure      400         {object} ErrorResponse
+// @Failure      401         {object} ErrorResponse
+// @Failure      403         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /playlists/{id}/items [post]
+func (h *MLibHandler) AddPlaylistItem(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering AddPlaylistItem handler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		log.Warnf("Invalid playlist ID: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	var input models.AddPlaylistItem
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Warnf("Failed to bind JSON for playlist item: %v", err)
+		c.JSON(bindStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Debugf("Adding a song to playlist ID %d", id)
+	item, err := h.Service.AddPlaylistItem(c.Request.Context(), id, input)
+	if err != nil {
+		log.Errorf("Failed to add song to playlist: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully added song to playlist")
+	c.JSON(http.StatusCreated, item)
+}
+
+// RemovePlaylistItem godoc
+// @Summary      Remove an item from a playlist
+// @Description  Removes an item from a playlist of the user making the request, shifting the following items up
+// @Tags         Playlists
+// @Accept       json
+// @Produce      json
+// @Param        X-User-ID   header   string  true  "User making the request"
+// @Param        id          path     int     true  "Playlist ID"
+// @Param        item        path     int     true  "Item ID"
+// @Success      204
+// @Failure      400         {object} ErrorResponse
+// @Failure      401         {object} ErrorResponse
+// @Failure      403         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /playlists/{id}/items/{item} [delete]
+func (h *MLibHandler) RemovePlaylistItem(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering RemovePlaylistItem handler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		log.Warnf("Invalid playlist ID: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("item"))
+	if err != nil {
+		log.Warnf("Invalid item ID: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Debugf("Removing item ID %d from playlist ID %d", itemId, id)
+	if err = h.Service.RemovePlaylistItem(c.Request.Context(), id, itemId); err != nil {
+		log.Errorf("Failed to remove playlist item: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully removed playlist item")
+	c.Status(http.StatusNoContent)
+}
+
+// MovePlaylistItem godoc
+// @Summary      Move an item of a playlist
+// @Description  Moves an item of a playlist of the user making the request to a position, shifting the items in between. A position past the end moves the item to the end
+// @Tags         Playlists
+// @Accept       json
+// @Produce      json
+// @Param        X-User-ID   header   string  true  "User making the request"
+// @Param        id          path     int     true  "Playlist ID"
+// @Param        item        path     int     true  "Item ID"
+// @Param        position    body     models.MovePlaylistItem true "New position"
+// @Success      200         {object} models.PlaylistItem
+// @Failure      400         {object} ErrorResponse
+// @Failure      401         {object} ErrorResponse
+// @Failure      403         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /playlists/{id}/items/{item}/position [put]
+func (h *MLibHandler) MovePlaylistItem(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering MovePlaylistItem handler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		log.Warnf("Invalid playlist ID: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("item"))
+	if err != nil {
+		log.Warnf("Invalid item ID: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	var input models.MovePlaylistItem
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Warnf("Failed to bind JSON for playlist item move: %v", err)
+		c.JSON(bindStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Debugf("Moving item ID %d of playlist ID %d", itemId, id)
+	item, err := h.Service.MovePlaylistItem(c.Request.Context(), id, itemId, input)
+	if err != nil {
+		log.Errorf("Failed to move playlist item: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully moved playlist item")
+	c.JSON(http.StatusOK, item)
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"music-library/internal/models"
+	"music-library/internal/utils"
+	"net/http"
+	"strconv"
+)
+
+// GetLyricsDiff godoc
+// @Summary      Compare lyrics revisions
+// @Description  Compares two stored revisions of the original lyrics of a song by line or by verse, as JSON hunks or a unified diff
+// @Tags         Lyrics
+// @Accept       json
+// @Produce      json,plain
+// @Param        id          path     int     true  "Song ID"
+// @Param        from        query    string  false "Revision number or current, defaults to the revision before to"
+// @Param        to          query    string  false "Revision number or current" default(current)
+// @Param        granularity query    string  false "Diff unit" Enums(line, verse) default(line)
+// @Param        format      query    string  false "Response format" Enums(json, unified) default(json)
+// @Success      200         {object} models.LyricsDiff
+// @Failure      400         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /songs/{id}/lyrics/diff [get]
+func (h *MLibHandler) GetLyricsDiff(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering GetLyricsDiff handler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		log.Warnf("Invalid song ID: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	format, ok := diffFormat(c)
+	if !ok {
+		return
+	}
+
+	log.Debugf("Comparing revisions %q and %q of song ID %d", c.Query("from"), c.Query("to"), id)
+	diff, err := h.Service.GetLyricsDiff(c.Request.Context(), id, c.Query("from"), c.Query("to"),
+		c.DefaultQuery("granularity", utils.DiffByLine))
+	if err != nil {
+		log.Errorf("Failed to compare lyrics revisions: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully compared lyrics revisions")
+	writeDiff(c, format, diff)
+}
+
+// DiffProposedLyrics godoc
+// @Summary      Preview a lyrics change
+// @Description  Compares the current original lyrics of a song with proposed lyrics without storing them. Lyrics of more than 5000 lines or verses are not compared
+// @Tags         Lyrics
+// @Accept       json
+// @Produce      json,plain
+// @Param        id          path     int     true  "Song ID"
+// @Param        granularity query    string  false "Diff unit" Enums(line, verse) default(line)
+// @Param        format      query    string  false "Response format" Enums(json, unified) default(json)
+// @Param        lyrics      body     models.ProposedLyrics true "Proposed lyrics"
+// @Success      200         {object} models.LyricsDiff
+// @Failure      400         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
+// @Failure      413         {object} ErrorResponse
+// @Failure      422         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /songs/{id}/lyrics/diff [post]
+func (h *MLibHandler) DiffProposedLyrics(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering DiffProposedLyrics handler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		log.Warnf("Invalid song ID: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	format, ok := diffFormat(c)
+	if !ok {
+		return
+	}
+
+	var req models.ProposedLyrics
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("Failed to bind JSON for proposed lyrics: %v", err)
+		c.JSON(bindStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Debugf("Comparing proposed lyrics with song ID %d", id)
+	diff, err := h.Service.DiffProposedLyrics(c.Request.Context(), id, *req.Text, c.DefaultQuery("granularity", utils.DiffByLine))
+	if err != nil {
+		log.Errorf("Failed to compare proposed lyrics: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully compared proposed lyrics")
+	writeDiff(c, format, diff)
+}
+
+// GetHistory godoc
+// @Summary      Get lyrics history
+// @Description  Lists a page of the revisions of the original lyrics of a song, newest first, with who made them and what they changed. Revisions of more than 5000 lines or verses are marked tooLarge instead of compared
+// @Tags         Lyrics
+// @Accept       json
+// @Produce      json
+// @Param        id          path     int     true  "Song ID"
+// @Param        granularity query    string  false "Diff unit" Enums(line, verse) default(line)
+// @Param        page        query    int     false "Page number" default(1)
+// @Param        limit       query    int     false "Page size"   default(10)
+// @Success      200         {array}  models.HistoryEntry
+// @Failure      400         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /songs/{id}/history [get]
+func (h *MLibHandler) GetHistory(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering GetHistory handler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		log.Warnf("Invalid song ID: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	page, limit, ok := h.pageParams(c)
+	if !ok {
+		return
+	}
+
+	log.Debugf("Fetching lyrics history for song ID %d with page %d and limit %d", id, page, limit)
+	history, err := h.Service.GetHistory(c.Request.Context(), id, c.DefaultQuery("granularity", utils.DiffByLine), page, limit)
+	if err != nil {
+		log.Errorf("Failed to fetch lyrics history: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully fetched lyrics history")
+	c.JSON(http.StatusOK, history)
+}
+
+// diffFormat reads the format query parameter, responding with 400 if it is not json or unified.
+func diffFormat(c *gin.Context) (string, bool) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "unified" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "format must be one of json, unified"})
+		return "", false
+	}
+	return format, true
+}
+
+func writeDiff(c *gin.Context, format string, diff models.LyricsDiff) {
+	if format == "unified" {
+		c.String(http.StatusOK, utils.UnifiedDiff(revisionName(diff.From), revisionName(diff.To), diff.Hunks))
+		return
+	}
+	c.JSON(http.StatusOK, diff)
+}
+
+// revisionName labels a side of a unified diff. Revision 0 stands for the empty lyrics before the first revision.
+func revisionName(revision string) string {
+	if revision == "0" {
+		return "/dev/null"
+	}
+	if _, err := strconv.Atoi(revision); err == nil {
+		return "revision " + revision
+	}
+	return revision
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// SearchVerses godoc
+// @Summary      Search the verses of a song
+// @Description  Finds the verses of a song containing a phrase, ignoring case, with the offsets of each match in characters and the page of GET /songs/{id} holding the verse
+// @Tags         Search
+// @Accept       json
+// @Produce      json
+// @Param        id          path     int     true  "Song ID"
+// @Param        q           query    string  true  "Phrase to search for"
+// @Param        pageSize    query    int     false "Page size of GET /songs/{id} used to compute pages" default(10)
+// @Param        lang        query    string  false "BCP-47 language of the lyrics, defaults to Accept-Language and then the original"
+// @Param        Accept-Language header string false "Preferred languages"
+// @Success      200         {array}  models.VerseMatch
+// @Failure      400         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /songs/{id}/verses/search [get]
+func (h *MLibHandler) SearchVerses(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering SearchVerses handler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		log.Warnf("Invalid song ID: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	pageSize, err := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	if err != nil {
+		log.Warnf("Invalid pageSize parameter: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	prefs, ok := h.languagePreferences(c)
+	if !ok {
+		return
+	}
+
+	log.Debugf("Searching verses of song ID %d", id)
+	matches, err := h.Service.SearchVerses(c.Request.Context(), id, c.Query("q"), pageSize, prefs)
+	if err != nil {
+		log.Errorf("Failed to search verses: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully searched verses of song")
+	c.JSON(http.StatusOK, matches)
+}
+
+// SearchLibraryVerses godoc
+// @Summary      Search verses across the library
+// @Description  Finds the verses containing a phrase in the original lyrics of a page of songs, ignoring case, with the offsets of each match in characters
+// @Tags         Search
+// @Accept       json
+// @Produce      json
+// @Param        q           query    string  true  "Phrase to search for"
+// @Param        page        query    int     false "Page number of matching songs" default(1)
+// @Param        limit       query    int     false "Matching songs per page" default(10)
+// @Param        pageSize    query    int     false "Page size of GET /songs/{id} used to compute pages" default(10)
+// @Success      200         {array}  models.VerseMatch
+// @Failure      400         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /verses/search [get]
+func (h *MLibHandler) SearchLibraryVerses(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering SearchLibraryVerses handler")
+
+	params := map[string]int{}
+	for name, def := range map[string]string{"page": "1", "limit": "10", "pageSize": "10"} {
+		value, err := strconv.Atoi(c.DefaultQuery(name, def))
+		if err != nil {
+			log.Warnf("Invalid %s parameter: %v", name, err)
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		params[name] = value
+	}
+
+	log.Debugf("Searching library verses, page %d, limit %d", params["page"], params["limit"])
+	matches, err := h.Service.SearchLibraryVerses(c.Request.Context(), c.Query("q"), params["page"], params["limit"], params["pageSize"])
+	if err != nil {
+		log.Errorf("Failed to search library verses: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully searched library verses")
+	c.JSON(http.StatusOK, matches)
+}
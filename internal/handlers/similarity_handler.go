@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// GetSimilarSongs godoc
+// @Summary      Get similar songs
+// @Description  Lists the songs most similar to a song by group, shared tags, release year and lyrics, the most similar first. Similar songs are recomputed in the background shortly after songs change, and at most 20 are kept
+// @Tags         Songs
+// @Accept       json
+// @Produce      json
+// @Param        id          path     int     true  "Song ID"
+// @Param        top         query    int     false "Number of songs" default(10)
+// @Success      200         {array}  models.SimilarSong
+// @Failure      400         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /songs/{id}/similar [get]
+func (h *MLibHandler) GetSimilarSongs(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering GetSimilarSongs handler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		log.Warnf("Invalid song ID: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	top, err := strconv.Atoi(c.DefaultQuery("top", "10"))
+	if err != nil {
+		log.Warnf("Invalid top parameter: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	songs, err := h.Service.GetSimilarSongs(c.Request.Context(), id, top)
+	if err != nil {
+		log.Errorf("Failed to get similar songs: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Infof("Successfully fetched %d similar songs", len(songs))
+	c.JSON(http.StatusOK, songs)
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// GetSongStats godoc
+// @Summary      Get song statistics
+// @Description  Computes verse, line and word counts, unique words, repeated lines, reading time and the most frequent terms without stopwords from the stored lyrics of a song
+// @Tags         Stats
+// @Accept       json
+// @Produce      json
+// @Param        id          path     int     true  "Song ID"
+// @Param        top         query    int     false "Number of most frequent terms" default(10)
+// @Param        lang        query    string  false "BCP-47 language of the lyrics, defaults to Accept-Language and then the original"
+// @Param        Accept-Language header string false "Preferred languages"
+// @Success      200         {object} models.SongStats
+// @Failure      400         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /songs/{id}/stats [get]
+func (h *MLibHandler) GetSongStats(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering GetSongStats handler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		log.Warnf("Invalid song ID: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	top, err := strconv.Atoi(c.DefaultQuery("top", "10"))
+	if err != nil {
+		log.Warnf("Invalid top parameter: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	prefs, ok := h.languagePreferences(c)
+	if !ok {
+		return
+	}
+
+	log.Debugf("Computing stats for song ID %d", id)
+	stats, err := h.Service.GetSongStats(c.Request.Context(), id, top, prefs)
+	if err != nil {
+		log.Errorf("Failed to compute song stats: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully computed song stats")
+	c.Header("Content-Language", stats.Language)
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetLibraryStats godoc
+// @Summary      Get library statistics
+// @Description  Aggregates songs per group, per release year and decade, the longest songs by word count and the groups with the most songs
+// @Tags         Stats
+// @Accept       json
+// @Produce      json
+// @Param        top         query    int     false "Number of longest songs and top groups" default(10)
+// @Success      200         {object} models.LibraryStats
+// @Failure      400         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /stats [get]
+func (h *MLibHandler) GetLibraryStats(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering GetLibraryStats handler")
+
+	top, err := strconv.Atoi(c.DefaultQuery("top", "10"))
+	if err != nil {
+		log.Warnf("Invalid top parameter: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Debug("Computing library stats")
+	stats, err := h.Service.GetLibraryStats(c.Request.Context(), top)
+	if err != nil {
+		log.Errorf("Failed to compute library stats: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully computed library stats")
+	c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// Suggest godoc
+// @Summary      Suggest group or song names
+// @Description  Suggests the group or song names best matching what was typed in a search box, ignoring case and diacritics and tolerating typos. Names starting with the query come first, then names with a word starting with it. Queries shorter than 3 characters only match the start of words. The route has its own deadline, SUGGEST_TIMEOUT
+// @Tags         Search
+// @Accept       json
+// @Produce      json
+// @Param        q           query    string  true  "Text typed so far"
+// @Param        type        query    string  false "Kind of names" Enums(group, song) default(song)
+// @Param        top         query    int     false "Number of suggestions, at most 50" default(10)
+// @Success      200         {array}  models.Suggestion
+// @Failure      400         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /suggest [get]
+func (h *MLibHandler) Suggest(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering Suggest handler")
+
+	top, err := strconv.Atoi(c.DefaultQuery("top", "10"))
+	if err != nil {
+		log.Warnf("Invalid top parameter: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	suggestions, err := h.Service.Suggest(c.Request.Context(), c.Query("q"), c.Query("type"), top)
+	if err != nil {
+		log.Errorf("Failed to suggest names: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Debugf("Successfully suggested %d names", len(suggestions))
+	c.JSON(http.StatusOK, suggestions)
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// GetTags godoc
+// @Summary      List tags
+// @Description  Lists every tag in use with its number of songs, the most used first
+// @Tags         Tags
+// @Accept       json
+// @Produce      json
+// @Success      200         {array}  models.TagCount
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /tags [get]
+func (h *MLibHandler) GetTags(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering GetTags handler")
+
+	tags, err := h.Service.GetTags(c.Request.Context())
+	if err != nil {
+		log.Errorf("Failed to get tags: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Infof("Successfully fetched %d tags", len(tags))
+	c.JSON(http.StatusOK, tags)
+}
+
+// DeleteTag godoc
+// @Summary      Delete a tag
+// @Description  Deletes a tag and removes it from every song
+// @Tags         Tags
+// @Accept       json
+// @Produce      json
+// @Param        tag         path     string  true  "Tag"
+// @Success      204
+// @Failure      400         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /tags/{tag} [delete]
+func (h *MLibHandler) DeleteTag(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering DeleteTag handler")
+
+	log.Debugf("Deleting tag %s", c.Param("tag"))
+	if err := h.Service.DeleteTag(c.Request.Context(), c.Param("tag")); err != nil {
+		log.Errorf("Failed to delete tag: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully deleted tag")
+	c.Status(http.StatusNoContent)
+}
+
+// GetSongTags godoc
+// @Summary      List the tags of a song
+// @Description  Lists the tags of a song in alphabetical order
+// @Tags         Tags
+// @Accept       json
+// @Produce      json
+// @Param        id          path     int     true  "Song ID"
+// @Success      200         {array}  string
+// @Failure      400         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /songs/{id}/tags [get]
+func (h *MLibHandler) GetSongTags(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering GetSongTags handler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		log.Warnf("Invalid song ID: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	tags, err := h.Service.GetSongTags(c.Request.Context(), id)
+	if err != nil {
+		log.Errorf("Failed to get song tags: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Infof("Successfully fetched %d tags", len(tags))
+	c.JSON(http.StatusOK, tags)
+}
+
+// AddSongTag godoc
+// @Summary      Tag a song
+// @Description  Adds a tag to a song, creating the tag if needed, and returns the song's tags. Tags are stored in lower case
+// @Tags         Tags
+// @Accept       json
+// @Produce      json
+// @Param        id          path     int     true  "Song ID"
+// @Param        tag         path     string  true  "Tag"
+// @Success      200         {array}  string
+// @Failure      400         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /songs/{id}/tags/{tag} [put]
+func (h *MLibHandler) AddSongTag(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering AddSongTag handler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		log.Warnf("Invalid song ID: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Debugf("Tagging song ID %d with %s", id, c.Param("tag"))
+	tags, err := h.Service.AddSongTag(c.Request.Context(), id, c.Param("tag"))
+	if err != nil {
+		log.Errorf("Failed to tag song: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully tagged song")
+	c.JSON(http.StatusOK, tags)
+}
+
+// RemoveSongTag godoc
+// @Summary      Untag a song
+// @Description  Removes a tag from a song. A tag no song carries any more is deleted
+// @Tags         Tags
+// @Accept       json
+// @Produce      json
+// @Param        id          path     int     true  "Song ID"
+// @Param        tag         path     string  true  "Tag"
+// @Success      204
+// @Failure      400         {object} ErrorResponse
+// @Failure      404         {object} ErrorResponse
+// @Failure      500         {object} ErrorResponse
+// @Failure      504         {object} ErrorResponse
+// @Router       /songs/{id}/tags/{tag} [delete]
+func (h *MLibHandler) RemoveSongTag(c *gin.Context) {
+	log := h.requestLogger(c)
+	log.Debug("Entering RemoveSongTag handler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		log.Warnf("Invalid song ID: %v", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Debugf("Removing tag %s from song ID %d", c.Param("tag"), id)
+	if err = h.Service.RemoveSongTag(c.Request.Context(), id, c.Param("tag")); err != nil {
+		log.Errorf("Failed to untag song: %v", err)
+		c.JSON(errorStatus(c, err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Info("Successfully untagged song")
+	c.Status(http.StatusNoContent)
+}
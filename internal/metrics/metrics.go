@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"time"
+)
+
+const namespace = "music_library"
+
+var (
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of HTTP requests by method, route template and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	RepoQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "repository",
+		Name:      "query_duration_seconds",
+		Help:      "Duration of repository calls by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	ExternalAPIRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "external_api",
+		Name:      "requests_total",
+		Help:      "Number of calls to the external song details API by result.",
+	}, []string{"result"})
+
+	ExternalAPIDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "external_api",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of calls to the external song details API.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	ExternalAPIBreakerState = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "external_api",
+		Name:      "breaker_state",
+		Help:      "State of the external API circuit breaker (0 closed, 1 half-open, 2 open).",
+	})
+
+	SongsAdded = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "songs_added_total",
+		Help:      "Number of songs added to the library.",
+	})
+
+	SongsEdited = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "songs_edited_total",
+		Help:      "Number of songs edited in the library.",
+	})
+
+	SongsDeleted = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "songs_deleted_total",
+		Help:      "Number of songs deleted from the library.",
+	})
+)
+
+// ObserveRepoQuery records the time elapsed since start for the given repository method.
+// It is meant to be deferred at the top of a repository method.
+func ObserveRepoQuery(method string, start time.Time) {
+	RepoQueryDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,50 @@
+package models
+
+const (
+	// BatchAtomic commits every operation or none of them.
+	BatchAtomic = "atomic"
+	// BatchBestEffort commits the operations that succeed and reports the others as failed.
+	BatchBestEffort = "best-effort"
+)
+
+const (
+	BatchOpAdd    = "add"
+	BatchOpEdit   = "edit"
+	BatchOpDelete = "delete"
+)
+
+const (
+	BatchStatusOK         = "ok"
+	BatchStatusFailed     = "failed"
+	BatchStatusRolledBack = "rolled_back"
+	BatchStatusSkipped    = "skipped"
+)
+
+// BatchOperation adds a song described by Song, applies Edit to the song ID or deletes the song ID.
+type BatchOperation struct {
+	Op   string    `json:"op" binding:"required,oneof=add edit delete" example:"edit"`
+	ID   *int      `json:"id,omitempty" example:"1"`
+	Song *AddSong  `json:"song,omitempty"`
+	Edit *EditSong `json:"edit,omitempty"`
+}
+
+type BatchRequest struct {
+	Mode       string           `json:"mode" binding:"omitempty,oneof=atomic best-effort" example:"atomic"`
+	Operations []BatchOperation `json:"operations" binding:"required,min=1,max=100,dive"`
+}
+
+// BatchResult is the outcome of an operation. ID is the song the operation applied to, or the new song of an add.
+type BatchResult struct {
+	Index  int    `json:"index" example:"0"`
+	Op     string `json:"op" example:"edit"`
+	ID     *int   `json:"id,omitempty" example:"1"`
+	Status string `json:"status" example:"ok"`
+	Error  string `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Mode      string        `json:"mode" example:"atomic"`
+	Committed bool          `json:"committed" example:"true"`
+	Error     string        `json:"error,omitempty"`
+	Results   []BatchResult `json:"results"`
+}
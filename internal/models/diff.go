@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+type DiffOp string
+
+const (
+	DiffEqual  DiffOp = "equal"
+	DiffInsert DiffOp = "insert"
+	DiffDelete DiffOp = "delete"
+)
+
+// DiffLine is a line (or verse) of a diff. OldNumber and NewNumber are 1-based and zero
+// for lines that do not exist on that side.
+type DiffLine struct {
+	Op        DiffOp `json:"op" example:"insert"`
+	Text      string `json:"text" example:"Take a sad song and make it better"`
+	OldNumber int    `json:"oldNumber,omitempty" example:"2"`
+	NewNumber int    `json:"newNumber,omitempty" example:"2"`
+}
+
+type DiffHunk struct {
+	OldStart int        `json:"oldStart" example:"1"`
+	OldCount int        `json:"oldCount" example:"4"`
+	NewStart int        `json:"newStart" example:"1"`
+	NewCount int        `json:"newCount" example:"5"`
+	Lines    []DiffLine `json:"lines"`
+}
+
+type LyricsDiff struct {
+	SongID      int        `json:"songId" example:"1"`
+	From        string     `json:"from" example:"1"`
+	To          string     `json:"to" example:"current"`
+	Granularity string     `json:"granularity" example:"line"`
+	Added       int        `json:"added" example:"1"`
+	Removed     int        `json:"removed" example:"0"`
+	Hunks       []DiffHunk `json:"hunks"`
+}
+
+// SongRevision is a stored version of the original lyrics of a song.
+type SongRevision struct {
+	Revision  int       `json:"revision" example:"2"`
+	Author    *string   `json:"author,omitempty" example:"curator-1"`
+	CreatedAt time.Time `json:"createdAt" example:"2024-01-02T15:04:05Z"`
+	Text      string    `json:"-"`
+}
+
+// HistoryEntry is a revision in the audit history of a song with the changes it made to the previous revision.
+// TooLarge marks a revision too long to compare, listed without its changes.
+type HistoryEntry struct {
+	SongRevision
+	Added    int        `json:"added" example:"1"`
+	Removed  int        `json:"removed" example:"0"`
+	Hunks    []DiffHunk `json:"hunks"`
+	TooLarge bool       `json:"tooLarge,omitempty" example:"false"`
+}
+
+type ProposedLyrics struct {
+	Text *string `json:"text" binding:"required" example:"Hey, Jude, don't make it bad"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// PopularSong is a song with its number of plays, in total or within a time window, and the
+// number of users who favorited it.
+type PopularSong struct {
+	SongID    int    `json:"songId" example:"1"`
+	Group     string `json:"group" example:"The Beatles"`
+	Song      string `json:"song" example:"Hey Jude"`
+	Plays     int64  `json:"plays" example:"1250"`
+	Favorites int    `json:"favorites" example:"42"`
+}
+
+// Play is a play of a song in a listening history.
+type Play struct {
+	SongID   int       `json:"songId" example:"1"`
+	Group    string    `json:"group" example:"The Beatles"`
+	Song     string    `json:"song" example:"Hey Jude"`
+	PlayedAt time.Time `json:"playedAt" example:"2024-01-02T15:04:05Z"`
+}
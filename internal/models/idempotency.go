@@ -0,0 +1,13 @@
+package models
+
+// IdempotentResponse is the stored response to a request made with an Idempotency-Key.
+// RequestHash identifies the request the key was first used with. Pending is set while that
+// request is still being handled, when there is no response yet.
+type IdempotentResponse struct {
+	RequestHash string
+	Pending     bool
+	StatusCode  int
+	ContentType string
+	Location    string
+	Body        []byte
+}
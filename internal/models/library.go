@@ -2,6 +2,12 @@ package models
 
 import "time"
 
+// Orders of the library.
+const (
+	SortID      = "id"
+	SortPopular = "popular"
+)
+
 type LibraryFilter struct {
 	ID          *int       `form:"id" example:"1"`
 	Group       *string    `form:"group" example:"The Beatles"`
@@ -11,4 +17,14 @@ type LibraryFilter struct {
 	Link        *string    `form:"link" example:"https://example.com/heyjude"`
 	Page        *int       `form:"page" example:"1"`
 	Limit       *int       `form:"limit" example:"10"`
+
+	// ExcludeExplicit leaves out songs flagged as explicit.
+	ExcludeExplicit *bool `form:"excludeExplicit" example:"true"`
+
+	// Tags keeps songs tagged with any, or with TagMatch "all" every, of the comma separated tags.
+	Tags     []string `form:"tags" example:"rock,70s"`
+	TagMatch string   `form:"tagMatch" example:"any"`
+
+	// Sort orders the songs by id, the default, or with "popular" by play count, the most played first.
+	Sort string `form:"sort" example:"popular"`
 }
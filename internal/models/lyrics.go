@@ -0,0 +1,110 @@
+package models
+
+import "strings"
+
+// LanguageUndetermined is the BCP-47 tag of lyrics whose language is not known.
+const LanguageUndetermined = "und"
+
+type SectionKind string
+
+const (
+	SectionIntro  SectionKind = "intro"
+	SectionVerse  SectionKind = "verse"
+	SectionChorus SectionKind = "chorus"
+	SectionBridge SectionKind = "bridge"
+	SectionOutro  SectionKind = "outro"
+)
+
+// LyricsWord is a word of an enhanced LRC line with its own start time.
+type LyricsWord struct {
+	StartMs int    `json:"startMs" example:"12500"`
+	Text    string `json:"text" example:"Jude"`
+}
+
+// LyricsLine is a single line of lyrics. StartMs is set for time-synced lyrics only.
+type LyricsLine struct {
+	Text    string       `json:"text" example:"Hey, Jude, don't make it bad"`
+	StartMs *int         `json:"startMs,omitempty" example:"12000"`
+	Words   []LyricsWord `json:"words,omitempty"`
+}
+
+type LyricsSection struct {
+	Kind  SectionKind  `json:"kind" example:"verse"`
+	Lines []LyricsLine `json:"lines"`
+}
+
+// ActiveLine is the line being sung at a given offset into a song.
+type ActiveLine struct {
+	SongID       int        `json:"songId" example:"1"`
+	OffsetMs     int        `json:"offsetMs" example:"12800"`
+	SectionIndex int        `json:"sectionIndex" example:"0"`
+	LineIndex    int        `json:"lineIndex" example:"1"`
+	Line         LyricsLine `json:"line"`
+}
+
+type Lyrics struct {
+	SongID   int             `json:"songId" example:"1"`
+	Language string          `json:"language" example:"en"`
+	Sections []LyricsSection `json:"sections"`
+}
+
+// Translation is a version of the lyrics of a song in one language. Exactly one version is the original.
+type Translation struct {
+	Language string `json:"language" example:"de"`
+	Original bool   `json:"original" example:"false"`
+}
+
+type PutTranslation struct {
+	Text     *string `json:"text" binding:"required" example:"Hey Jude, mach es nicht schlecht"`
+	Original bool    `json:"original" example:"false"`
+}
+
+// AlignedVerse holds the verse at the same index in several languages, keyed by language tag.
+type AlignedVerse struct {
+	Index  int               `json:"index" example:"0"`
+	Verses map[string]string `json:"verses"`
+}
+
+// Verses returns every section as a single newline separated string, in order.
+func (l Lyrics) Verses() []string {
+	verses := make([]string, 0, len(l.Sections))
+	for _, section := range l.Sections {
+		verses = append(verses, section.Text())
+	}
+	return verses
+}
+
+// Synced reports whether every line of the lyrics has a start time.
+func (l Lyrics) Synced() bool {
+	if len(l.Sections) == 0 {
+		return false
+	}
+	for _, section := range l.Sections {
+		for _, line := range section.Lines {
+			if line.StartMs == nil {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Text returns the lines of the section separated by newlines.
+func (s LyricsSection) Text() string {
+	lines := make([]string, 0, len(s.Lines))
+	for _, line := range s.Lines {
+		lines = append(lines, line.Text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Text renders the lyrics back to plain text with sections separated by a blank line.
+func (l Lyrics) Text() string {
+	return strings.Join(l.Verses(), "\n\n")
+}
+
+// ContentFlags is the result of classifying lyrics for explicit content.
+type ContentFlags struct {
+	Explicit       bool `json:"explicit" example:"false"`
+	ProfanityCount int  `json:"profanityCount" example:"0"`
+}
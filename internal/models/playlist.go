@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Playlist is a list of songs owned by the user who created it. Length is its number of items.
+type Playlist struct {
+	ID          int       `json:"id" example:"1"`
+	Owner       string    `json:"owner" example:"listener-1"`
+	Name        string    `json:"name" example:"Road trip"`
+	Description *string   `json:"description,omitempty" example:"Songs to sing along to"`
+	Length      int       `json:"length" example:"12"`
+	CreatedAt   time.Time `json:"createdAt" example:"2024-01-02T15:04:05Z"`
+	UpdatedAt   time.Time `json:"updatedAt" example:"2024-01-02T15:04:05Z"`
+}
+
+// PlaylistItem is a song at a position of a playlist, counted from 1. An item whose song was
+// deleted stays in the playlist as unavailable, with no song id and the names the song had.
+type PlaylistItem struct {
+	ID        int       `json:"id" example:"7"`
+	Position  int       `json:"position" example:"1"`
+	SongID    *int      `json:"songId" example:"1"`
+	Group     string    `json:"group" example:"The Beatles"`
+	Song      string    `json:"song" example:"Hey Jude"`
+	Available bool      `json:"available" example:"true"`
+	AddedAt   time.Time `json:"addedAt" example:"2024-01-02T15:04:05Z"`
+}
+
+// PlaylistPage is a playlist with a page of its items.
+type PlaylistPage struct {
+	Playlist
+	Items []PlaylistItem `json:"items"`
+}
+
+// PlaylistInput names and describes a new playlist, or replaces the name and description of one.
+type PlaylistInput struct {
+	Name        *string `json:"name" binding:"required" example:"Road trip"`
+	Description *string `json:"description" example:"Songs to sing along to"`
+}
+
+// AddPlaylistItem adds a song at Position, shifting the items from there on down. Without a
+// position, or past the end, the song is appended.
+type AddPlaylistItem struct {
+	SongID   *int `json:"songId" binding:"required" example:"1"`
+	Position *int `json:"position" example:"1"`
+}
+
+// MovePlaylistItem moves an item to Position, shifting the items in between. A position past the
+// end moves the item to the end.
+type MovePlaylistItem struct {
+	Position *int `json:"position" binding:"required" example:"1"`
+}
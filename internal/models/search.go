@@ -0,0 +1,17 @@
+package models
+
+// MatchOffset is the position of a match in a verse, in characters, with End exclusive.
+type MatchOffset struct {
+	Start int `json:"start" example:"0"`
+	End   int `json:"end" example:"8"`
+}
+
+// VerseMatch is a verse containing the searched phrase. Page is the page of GET /songs/{id}
+// holding the verse for the page size used in the search.
+type VerseMatch struct {
+	SongID  int           `json:"songId" example:"1"`
+	Index   int           `json:"index" example:"0"`
+	Page    int           `json:"page" example:"1"`
+	Text    string        `json:"text" example:"Hey, Jude, don't make it bad\nTake a sad song and make it better"`
+	Matches []MatchOffset `json:"matches"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// SimilarSong is a song related to another, with its score and the parts making it up.
+// YearGap is nil when either release date is unknown.
+type SimilarSong struct {
+	SongID           int     `json:"songId" example:"2"`
+	Group            string  `json:"group" example:"The Beatles"`
+	Song             string  `json:"song" example:"Let It Be"`
+	Score            float64 `json:"score" example:"0.58"`
+	SameGroup        bool    `json:"sameGroup" example:"true"`
+	SharedTags       int     `json:"sharedTags" example:"2"`
+	YearGap          *int    `json:"yearGap,omitempty" example:"2"`
+	LyricsSimilarity float64 `json:"lyricsSimilarity" example:"0.21"`
+}
+
+// SongFeatures are the parts of a song compared to find similar songs.
+type SongFeatures struct {
+	ID          int
+	GroupID     int
+	ReleaseDate time.Time
+	Text        string
+	Tags        []string
+}
+
+// SimilarityJob is a song queued for its similar songs to be recomputed.
+type SimilarityJob struct {
+	SongID   int
+	QueuedAt time.Time
+}
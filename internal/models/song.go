@@ -22,16 +22,26 @@ type Song struct {
 	ReleaseDate *time.Time `json:"releaseDate" time_format:"2006-01-02" example:"1968-08-26"`
 	Text        *string    `json:"text" example:"Hey, Jude, don't make it bad\nTake a sad song and make it better\nRemember to let her into your heart\nThen you can start to make it better\n\nHey, Jude, don't be afraid You were made to go out and get her\nThe minute you let her under your skin\nThen you begin to make it better\nAnd anytime you feel the pain, hey, Jude, refrain\nDon't carry the world upon your shoulders\nFor well you know that it's a fool who plays it cool\nBy making his world a little colder\nNa-na-na-na-na, na-na-na-na\n\nHey, Jude, don't let me down\n\nYou have found her, now go and get her\n(Let it out and let it in)\nRemember (Hey, Jude) to let her into your heart\nThen you can start to make it better"`
 	Link        *string    `json:"link" example:"https://example.com/heyjude"`
+
+	// Explicit and ProfanityCount are computed from Text by the service.
+	Explicit       *bool `json:"explicit" example:"false"`
+	ProfanityCount *int  `json:"profanityCount" example:"0"`
+
+	// Genre is reported by the song details API and stored as a tag of a new song.
+	// It is not part of the song's JSON.
+	Genre *string `json:"genre" swaggerignore:"true"`
 }
 
 func (s *Song) MarshalJSON() ([]byte, error) {
 	type Alias struct {
-		ID          *int    `json:"id"`
-		Group       *string `json:"group"`
-		Song        *string `json:"song"`
-		ReleaseDate *string `json:"releaseDate"`
-		Text        *string `json:"text"`
-		Link        *string `json:"link"`
+		ID             *int    `json:"id"`
+		Group          *string `json:"group"`
+		Song           *string `json:"song"`
+		ReleaseDate    *string `json:"releaseDate"`
+		Text           *string `json:"text"`
+		Link           *string `json:"link"`
+		Explicit       *bool   `json:"explicit,omitempty"`
+		ProfanityCount *int    `json:"profanityCount,omitempty"`
 	}
 
 	var formattedDate *string
@@ -41,12 +51,14 @@ func (s *Song) MarshalJSON() ([]byte, error) {
 	}
 
 	aux := &Alias{
-		ID:          s.ID,
-		Group:       s.Group,
-		Song:        s.Song,
-		ReleaseDate: formattedDate,
-		Text:        s.Text,
-		Link:        s.Link,
+		ID:             s.ID,
+		Group:          s.Group,
+		Song:           s.Song,
+		ReleaseDate:    formattedDate,
+		Text:           s.Text,
+		Link:           s.Link,
+		Explicit:       s.Explicit,
+		ProfanityCount: s.ProfanityCount,
 	}
 	return json.Marshal(aux)
 }
@@ -170,6 +182,23 @@ func (s *EditSong) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// SongUpdate holds the new values of the columns changed by an edit. Nil fields are left as
+// they are. Explicit and ProfanityCount are derived from Text by the service.
+type SongUpdate struct {
+	Group          *string
+	Song           *string
+	ReleaseDate    *time.Time
+	Text           *string
+	Link           *string
+	Explicit       *bool
+	ProfanityCount *int
+}
+
+// Empty reports whether the update changes nothing.
+func (u SongUpdate) Empty() bool {
+	return u == SongUpdate{}
+}
+
 //type AddSong struct {
 //	Group       *string    `json:"group" example:"The Beatles"`
 //	Song        *string    `json:"song" example:"Hey Jude"`
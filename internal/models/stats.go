@@ -0,0 +1,52 @@
+package models
+
+type TermCount struct {
+	Term  string `json:"term" example:"jude"`
+	Count int    `json:"count" example:"6"`
+}
+
+type RepeatedLine struct {
+	Text  string `json:"text" example:"Then you can start to make it better"`
+	Count int    `json:"count" example:"2"`
+}
+
+type SongStats struct {
+	SongID             int            `json:"songId" example:"1"`
+	Language           string         `json:"language" example:"en"`
+	Verses             int            `json:"verses" example:"4"`
+	Lines              int            `json:"lines" example:"18"`
+	Words              int            `json:"words" example:"140"`
+	UniqueWords        int            `json:"uniqueWords" example:"85"`
+	ChorusSections     int            `json:"chorusSections" example:"1"`
+	RepeatedLines      []RepeatedLine `json:"repeatedLines"`
+	ReadingTimeSeconds int            `json:"readingTimeSeconds" example:"42"`
+	TopTerms           []TermCount    `json:"topTerms"`
+}
+
+type GroupCount struct {
+	Group string `json:"group" example:"The Beatles"`
+	Songs int    `json:"songs" example:"12"`
+}
+
+// PeriodCount counts songs released in a year or in a decade named by its first year.
+type PeriodCount struct {
+	Period int `json:"period" example:"1968"`
+	Songs  int `json:"songs" example:"3"`
+}
+
+type SongLength struct {
+	SongID int    `json:"songId" example:"1"`
+	Group  string `json:"group" example:"The Beatles"`
+	Song   string `json:"song" example:"Hey Jude"`
+	Words  int    `json:"words" example:"140"`
+}
+
+type LibraryStats struct {
+	Songs          int           `json:"songs" example:"120"`
+	Groups         int           `json:"groups" example:"15"`
+	SongsPerGroup  []GroupCount  `json:"songsPerGroup"`
+	SongsPerYear   []PeriodCount `json:"songsPerYear"`
+	SongsPerDecade []PeriodCount `json:"songsPerDecade"`
+	LongestSongs   []SongLength  `json:"longestSongs"`
+	TopGroups      []GroupCount  `json:"topGroups"`
+}
@@ -0,0 +1,16 @@
+package models
+
+// Kinds of names suggested by GET /suggest.
+const (
+	SuggestGroups = "group"
+	SuggestSongs  = "song"
+)
+
+// Suggestion is a group or song name matching what was typed in a search box. ID is the ID of
+// the group or song, and Group is only set for songs.
+type Suggestion struct {
+	ID    int     `json:"id" example:"1"`
+	Name  string  `json:"name" example:"Hey Jude"`
+	Group *string `json:"group,omitempty" example:"The Beatles"`
+	Score float64 `json:"score" example:"0.5"`
+}
@@ -0,0 +1,13 @@
+package models
+
+// How a library filter matches several tags.
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+)
+
+// TagCount is a tag with the number of songs carrying it.
+type TagCount struct {
+	Name  string `json:"name" example:"rock"`
+	Songs int    `json:"songs" example:"12"`
+}
@@ -0,0 +1,303 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"music-library/internal/metrics"
+	"music-library/internal/models"
+	"music-library/internal/utils"
+	"time"
+)
+
+// lockSongTx locks a song within tx, so that it cannot be deleted before rows referring to it
+// are written.
+func lockSongTx(ctx context.Context, tx pgx.Tx, id int) error {
+	var found int
+	err := tx.QueryRow(ctx, "SELECT id FROM songs WHERE id = $1 FOR SHARE", id).Scan(&found)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("song %d: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("lock song: %w", err)
+	}
+	return nil
+}
+
+// AddFavorite marks a song as a favorite of user. Favoriting a song twice is not an error.
+func (r *MLibRepository) AddFavorite(ctx context.Context, user string, id int) error {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("AddFavorite", time.Now())
+	log.Infof("AddFavorite called with user: %s, song ID: %d", user, id)
+
+	tx, err := r.BeginTx(ctx)
+	if err != nil {
+		log.Errorf("Failed to begin transaction: %v", err)
+		return fmt.Errorf("mlib_repo: addFavorite: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err = lockSongTx(ctx, tx, id); err != nil {
+		log.Warnf("Failed to lock song ID %d: %v", id, err)
+		return fmt.Errorf("mlib_repo: addFavorite: %w", err)
+	}
+
+	result, err := tx.Exec(ctx, "INSERT INTO favorites (user_id, song_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", user, id)
+	if err != nil {
+		log.Errorf("Error inserting favorite: %v", err)
+		return fmt.Errorf("mlib_repo: addFavorite: insert: %w", err)
+	}
+	if result.RowsAffected() > 0 {
+		_, err = tx.Exec(ctx, `INSERT INTO song_popularity (song_id, favorites) VALUES ($1, 1)
+			ON CONFLICT (song_id) DO UPDATE SET favorites = song_popularity.favorites + 1`, id)
+		if err != nil {
+			log.Errorf("Error counting favorite of song ID %d: %v", id, err)
+			return fmt.Errorf("mlib_repo: addFavorite: count favorite: %w", err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Errorf("Transaction commit failed: %v", err)
+		return fmt.Errorf("mlib_repo: addFavorite: commit transaction: %w", err)
+	}
+	log.Infof("Successfully favorited song ID %d", id)
+	return nil
+}
+
+// RemoveFavorite removes a song from the favorites of user.
+func (r *MLibRepository) RemoveFavorite(ctx context.Context, user string, id int) error {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("RemoveFavorite", time.Now())
+	log.Infof("RemoveFavorite called with user: %s, song ID: %d", user, id)
+
+	tx, err := r.BeginTx(ctx)
+	if err != nil {
+		log.Errorf("Failed to begin transaction: %v", err)
+		return fmt.Errorf("mlib_repo: removeFavorite: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, "DELETE FROM favorites WHERE user_id = $1 AND song_id = $2", user, id)
+	if err != nil {
+		log.Errorf("Error deleting favorite: %v", err)
+		return fmt.Errorf("mlib_repo: removeFavorite: delete: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		log.Warnf("Song ID %d is not a favorite of %s", id, user)
+		return fmt.Errorf("mlib_repo: removeFavorite: favorite %d: %w", id, ErrNotFound)
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE song_popularity SET favorites = favorites - 1 WHERE song_id = $1", id)
+	if err != nil {
+		log.Errorf("Error counting favorite of song ID %d: %v", id, err)
+		return fmt.Errorf("mlib_repo: removeFavorite: count favorite: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Errorf("Transaction commit failed: %v", err)
+		return fmt.Errorf("mlib_repo: removeFavorite: commit transaction: %w", err)
+	}
+	log.Infof("Successfully unfavorited song ID %d", id)
+	return nil
+}
+
+// GetFavorites returns a page of the favorite songs of user, the most recently favorited first.
+func (r *MLibRepository) GetFavorites(ctx context.Context, user string, page, limit int) ([]models.Song, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("GetFavorites", time.Now())
+	log.Infof("Entering GetFavorites function for user: %s", user)
+
+	conn, err := r.acquireRead(ctx)
+	if err != nil {
+		log.Error("Failed to acquire DB connection:", err)
+		return nil, fmt.Errorf("mlib_repo: getFavorites: db acquire: %w", err)
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, librarySQL+`
+		JOIN favorites AS f ON f.song_id = s.id
+		WHERE f.user_id = $1
+		ORDER BY f.created_at DESC, s.id
+		LIMIT $2 OFFSET $3`, user, limit, (page-1)*limit)
+	if err != nil {
+		log.Error("Query execution failed:", err)
+		return nil, fmt.Errorf("mlib_repo: getFavorites: query: %w", err)
+	}
+	songs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Song, error) {
+		var song models.Song
+		err := row.Scan(&song.ID, &song.Group, &song.Song, &song.ReleaseDate, &song.Text, &song.Link, &song.Explicit, &song.ProfanityCount)
+		return song, err
+	})
+	if err != nil {
+		log.Error("Rows scanning failed:", err)
+		return nil, fmt.Errorf("mlib_repo: getFavorites: rows: %w", err)
+	}
+
+	log.Infof("Successfully fetched %d favorite songs", len(songs))
+	return songs, nil
+}
+
+// RecordPlay stores a play of a song, by user when known, and counts it in the rollups.
+func (r *MLibRepository) RecordPlay(ctx context.Context, user *string, id int) error {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("RecordPlay", time.Now())
+	log.Infof("RecordPlay called with song ID: %d", id)
+
+	tx, err := r.BeginTx(ctx)
+	if err != nil {
+		log.Errorf("Failed to begin transaction: %v", err)
+		return fmt.Errorf("mlib_repo: recordPlay: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err = lockSongTx(ctx, tx, id); err != nil {
+		log.Warnf("Failed to lock song ID %d: %v", id, err)
+		return fmt.Errorf("mlib_repo: recordPlay: %w", err)
+	}
+
+	var playedAt time.Time
+	err = tx.QueryRow(ctx, "INSERT INTO song_plays (song_id, user_id) VALUES ($1, $2) RETURNING played_at", id, user).Scan(&playedAt)
+	if err != nil {
+		log.Errorf("Error inserting play of song ID %d: %v", id, err)
+		return fmt.Errorf("mlib_repo: recordPlay: insert: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `INSERT INTO song_popularity (song_id, plays) VALUES ($1, 1)
+		ON CONFLICT (song_id) DO UPDATE SET plays = song_popularity.plays + 1`, id)
+	if err != nil {
+		log.Errorf("Error counting play of song ID %d: %v", id, err)
+		return fmt.Errorf("mlib_repo: recordPlay: count play: %w", err)
+	}
+
+	// Days are UTC days, whatever the time zone of the database session.
+	_, err = tx.Exec(ctx, `INSERT INTO song_daily_plays (song_id, day, plays) VALUES ($1, $2, 1)
+		ON CONFLICT (song_id, day) DO UPDATE SET plays = song_daily_plays.plays + 1`, id, playedAt.UTC().Truncate(24*time.Hour))
+	if err != nil {
+		log.Errorf("Error counting daily play of song ID %d: %v", id, err)
+		return fmt.Errorf("mlib_repo: recordPlay: count daily play: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Errorf("Transaction commit failed: %v", err)
+		return fmt.Errorf("mlib_repo: recordPlay: commit transaction: %w", err)
+	}
+	log.Infof("Successfully recorded play of song ID %d", id)
+	return nil
+}
+
+// GetPlays returns a page of the listening history of user, the latest play first.
+func (r *MLibRepository) GetPlays(ctx context.Context, user string, page, limit int) ([]models.Play, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("GetPlays", time.Now())
+	log.Infof("Entering GetPlays function for user: %s", user)
+
+	conn, err := r.acquireRead(ctx)
+	if err != nil {
+		log.Error("Failed to acquire DB connection:", err)
+		return nil, fmt.Errorf("mlib_repo: getPlays: db acquire: %w", err)
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, `SELECT s.id, g.group_name, s.song_name, p.played_at
+		FROM song_plays AS p
+		JOIN songs AS s ON s.id = p.song_id
+		JOIN groups AS g ON g.id = s.group_id
+		WHERE p.user_id = $1
+		ORDER BY p.played_at DESC, p.id DESC
+		LIMIT $2 OFFSET $3`, user, limit, (page-1)*limit)
+	if err != nil {
+		log.Error("Query execution failed:", err)
+		return nil, fmt.Errorf("mlib_repo: getPlays: query: %w", err)
+	}
+	plays, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Play, error) {
+		var play models.Play
+		err := row.Scan(&play.SongID, &play.Group, &play.Song, &play.PlayedAt)
+		return play, err
+	})
+	if err != nil {
+		log.Error("Rows scanning failed:", err)
+		return nil, fmt.Errorf("mlib_repo: getPlays: rows: %w", err)
+	}
+
+	log.Infof("Successfully fetched %d plays", len(plays))
+	return plays, nil
+}
+
+// GetPopularSongs returns up to top of the most played songs, of the whole library or of the
+// group with the given name.
+func (r *MLibRepository) GetPopularSongs(ctx context.Context, group *string, top int) ([]models.PopularSong, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("GetPopularSongs", time.Now())
+	log.Infof("Entering GetPopularSongs function with top: %d", top)
+
+	conn, err := r.acquireRead(ctx)
+	if err != nil {
+		log.Error("Failed to acquire DB connection:", err)
+		return nil, fmt.Errorf("mlib_repo: getPopularSongs: db acquire: %w", err)
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, `SELECT s.id, g.group_name, s.song_name, sp.plays, sp.favorites
+		FROM song_popularity AS sp
+		JOIN songs AS s ON s.id = sp.song_id
+		JOIN groups AS g ON g.id = s.group_id
+		WHERE sp.plays > 0 AND ($1::text IS NULL OR g.group_name = $1)
+		ORDER BY sp.plays DESC, s.id
+		LIMIT $2`, group, top)
+	if err != nil {
+		log.Error("Query execution failed:", err)
+		return nil, fmt.Errorf("mlib_repo: getPopularSongs: query: %w", err)
+	}
+	songs, err := pgx.CollectRows(rows, scanPopularSong)
+	if err != nil {
+		log.Error("Rows scanning failed:", err)
+		return nil, fmt.Errorf("mlib_repo: getPopularSongs: rows: %w", err)
+	}
+
+	log.Infof("Successfully fetched %d popular songs", len(songs))
+	return songs, nil
+}
+
+// GetTrendingSongs returns up to top of the songs played the most in the last days UTC days,
+// today included.
+func (r *MLibRepository) GetTrendingSongs(ctx context.Context, days, top int) ([]models.PopularSong, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("GetTrendingSongs", time.Now())
+	log.Infof("Entering GetTrendingSongs function with days: %d, top: %d", days, top)
+
+	conn, err := r.acquireRead(ctx)
+	if err != nil {
+		log.Error("Failed to acquire DB connection:", err)
+		return nil, fmt.Errorf("mlib_repo: getTrendingSongs: db acquire: %w", err)
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, `SELECT s.id, g.group_name, s.song_name, SUM(dp.plays)::bigint, COALESCE(sp.favorites, 0)
+		FROM song_daily_plays AS dp
+		JOIN songs AS s ON s.id = dp.song_id
+		JOIN groups AS g ON g.id = s.group_id
+		LEFT JOIN song_popularity AS sp ON sp.song_id = s.id
+		WHERE dp.day > (now() AT TIME ZONE 'UTC')::date - $1::int
+		GROUP BY s.id, g.group_name, s.song_name, sp.favorites
+		ORDER BY SUM(dp.plays) DESC, s.id
+		LIMIT $2`, days, top)
+	if err != nil {
+		log.Error("Query execution failed:", err)
+		return nil, fmt.Errorf("mlib_repo: getTrendingSongs: query: %w", err)
+	}
+	songs, err := pgx.CollectRows(rows, scanPopularSong)
+	if err != nil {
+		log.Error("Rows scanning failed:", err)
+		return nil, fmt.Errorf("mlib_repo: getTrendingSongs: rows: %w", err)
+	}
+
+	log.Infof("Successfully fetched %d trending songs", len(songs))
+	return songs, nil
+}
+
+func scanPopularSong(row pgx.CollectableRow) (models.PopularSong, error) {
+	var song models.PopularSong
+	err := row.Scan(&song.SongID, &song.Group, &song.Song, &song.Plays, &song.Favorites)
+	return song, err
+}
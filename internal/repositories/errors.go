@@ -0,0 +1,12 @@
+package repositories
+
+import "errors"
+
+var (
+	// ErrNotFound is returned when the requested row does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when a change would leave the data in an invalid state.
+	ErrConflict = errors.New("conflict")
+	// ErrForbidden is returned when a row may only be changed by its owner.
+	ErrForbidden = errors.New("forbidden")
+)
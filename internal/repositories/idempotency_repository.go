@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"music-library/internal/metrics"
+	"music-library/internal/models"
+	"music-library/internal/utils"
+	"time"
+)
+
+// ClaimIdempotencyKey claims key for a request with requestHash until lease has passed. A key
+// holding an unexpired response or claim is not claimed and its entry is returned instead, with
+// Pending set for a claim. Every statement runs on its own, so no connection is held while the
+// request is handled.
+func (r *MLibRepository) ClaimIdempotencyKey(ctx context.Context, key, requestHash string, lease time.Duration) (*models.IdempotentResponse, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("ClaimIdempotencyKey", time.Now())
+	log.Debugf("ClaimIdempotencyKey called with key: %s", key)
+
+	// The entry found may expire before it is read, and the claim is then tried again.
+	for {
+		tag, err := r.db.Exec(ctx, `INSERT INTO idempotency_keys (key, request_hash, expires_at)
+			VALUES ($1, $2, now() + $3::INTERVAL)
+			ON CONFLICT (key) DO UPDATE SET request_hash = EXCLUDED.request_hash, status_code = NULL,
+				content_type = NULL, location = DEFAULT, response_body = NULL, created_at = now(),
+				expires_at = EXCLUDED.expires_at
+			WHERE idempotency_keys.expires_at <= now()`, key, requestHash, lease)
+		if err != nil {
+			log.Errorf("Failed to claim idempotency key %s: %v", key, err)
+			return nil, fmt.Errorf("mlib_repo: claimIdempotencyKey: upsert: %w", err)
+		}
+		if tag.RowsAffected() == 1 {
+			return nil, nil
+		}
+
+		var stored models.IdempotentResponse
+		var statusCode *int
+		var contentType *string
+		err = r.db.QueryRow(ctx, `SELECT request_hash, status_code, content_type, location, response_body
+			FROM idempotency_keys
+			WHERE key = $1 AND expires_at > now()`, key).Scan(&stored.RequestHash, &statusCode, &contentType,
+			&stored.Location, &stored.Body)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			log.Errorf("Failed to read idempotency key %s: %v", key, err)
+			return nil, fmt.Errorf("mlib_repo: claimIdempotencyKey: select: %w", err)
+		}
+
+		if statusCode == nil {
+			stored.Pending = true
+		} else {
+			stored.StatusCode, stored.ContentType = *statusCode, *contentType
+		}
+		return &stored, nil
+	}
+}
+
+// SaveIdempotentResponse stores the response to the request that claimed key with requestHash,
+// to be replayed for ttl. It fails with ErrNotFound when the claim was lost meanwhile.
+func (r *MLibRepository) SaveIdempotentResponse(ctx context.Context, key, requestHash string, response models.IdempotentResponse, ttl time.Duration) error {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("SaveIdempotentResponse", time.Now())
+
+	tag, err := r.db.Exec(ctx, `UPDATE idempotency_keys
+		SET status_code = $3, content_type = $4, location = $5, response_body = $6, created_at = now(),
+			expires_at = now() + $7::INTERVAL
+		WHERE key = $1 AND request_hash = $2 AND status_code IS NULL`,
+		key, requestHash, response.StatusCode, response.ContentType, response.Location, response.Body, ttl)
+	if err != nil {
+		log.Errorf("Failed to store idempotency key %s: %v", key, err)
+		return fmt.Errorf("mlib_repo: saveIdempotentResponse: update: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("mlib_repo: saveIdempotentResponse: claim of key %q: %w", key, ErrNotFound)
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey gives up the claim of the request with requestHash on key, so that the
+// request can be retried right away.
+func (r *MLibRepository) ReleaseIdempotencyKey(ctx context.Context, key, requestHash string) error {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("ReleaseIdempotencyKey", time.Now())
+
+	_, err := r.db.Exec(ctx, "DELETE FROM idempotency_keys WHERE key = $1 AND request_hash = $2 AND status_code IS NULL",
+		key, requestHash)
+	if err != nil {
+		log.Errorf("Failed to release idempotency key %s: %v", key, err)
+		return fmt.Errorf("mlib_repo: releaseIdempotencyKey: delete: %w", err)
+	}
+	return nil
+}
+
+// PurgeIdempotencyKeys deletes expired idempotency keys, and claims of requests that never
+// finished, and returns how many were deleted.
+func (r *MLibRepository) PurgeIdempotencyKeys(ctx context.Context) (int64, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("PurgeIdempotencyKeys", time.Now())
+
+	tag, err := r.db.Exec(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= now()")
+	if err != nil {
+		log.Errorf("Failed to purge idempotency keys: %v", err)
+		return 0, fmt.Errorf("mlib_repo: purgeIdempotencyKeys: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
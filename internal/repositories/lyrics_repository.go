@@ -0,0 +1,347 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"music-library/internal/metrics"
+	"music-library/internal/models"
+	"music-library/internal/utils"
+	"time"
+)
+
+// GetTranslations lists the lyrics versions of a song, the original first.
+func (r *MLibRepository) GetTranslations(ctx context.Context, id int) ([]models.Translation, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("GetTranslations", time.Now())
+	log.Infof("Entering GetTranslations function for song ID: %d", id)
+
+	conn, err := r.acquireRead(ctx)
+	if err != nil {
+		log.Error("Failed to acquire DB connection:", err)
+		return nil, fmt.Errorf("mlib_repo: getTranslations: db acquire: %w", err)
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, getTranslationsSQL, id)
+	if err != nil {
+		log.Error("Query execution failed:", err)
+		return nil, fmt.Errorf("mlib_repo: getTranslations: query: %w", err)
+	}
+	defer rows.Close()
+
+	var translations []models.Translation
+	for rows.Next() {
+		var translation models.Translation
+		if err := rows.Scan(&translation.Language, &translation.Original); err != nil {
+			log.Error("Row scanning failed:", err)
+			return nil, fmt.Errorf("mlib_repo: getTranslations: rows scan: %w", err)
+		}
+		translations = append(translations, translation)
+	}
+	if err := rows.Err(); err != nil {
+		log.Error("Rows iteration failed:", err)
+		return nil, fmt.Errorf("mlib_repo: getTranslations: rows: %w", err)
+	}
+
+	log.Infof("Successfully fetched %d translations for song ID: %d", len(translations), id)
+	return translations, nil
+}
+
+// GetLyricsText returns the plain text of the lyrics of a song in the given language.
+func (r *MLibRepository) GetLyricsText(ctx context.Context, id int, language string) (string, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("GetLyricsText", time.Now())
+	log.Infof("Entering GetLyricsText function for song ID: %d, language: %s", id, language)
+
+	conn, err := r.acquireRead(ctx)
+	if err != nil {
+		log.Error("Failed to acquire DB connection:", err)
+		return "", fmt.Errorf("mlib_repo: getLyricsText: db acquire: %w", err)
+	}
+	defer conn.Release()
+
+	var text string
+	err = conn.QueryRow(ctx, getLyricsTextSQL, id, language).Scan(&text)
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Warnf("No %s lyrics for song ID: %d", language, id)
+		return "", fmt.Errorf("mlib_repo: getLyricsText: %w", ErrNotFound)
+	}
+	if err != nil {
+		log.Error("QueryRow failed:", err)
+		return "", fmt.Errorf("mlib_repo: getLyricsText: queryRow: %w", err)
+	}
+
+	log.Infof("Successfully fetched %s lyrics for song ID: %d", language, id)
+	return text, nil
+}
+
+// PutTranslation creates or replaces the lyrics of a song in the given language. Marking them as
+// original moves the original flag from the previous version, updates the song text and its
+// content flags and queues the song for its similarities to be recomputed. Storing a version in
+// the language of the original updates the original.
+func (r *MLibRepository) PutTranslation(ctx context.Context, id int, language, text string, original bool, lyrics models.Lyrics, flags models.ContentFlags) error {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("PutTranslation", time.Now())
+	log.Infof("PutTranslation called with song ID: %d, language: %s, original: %t", id, language, original)
+
+	conn, err := r.db.Acquire(ctx)
+	if err != nil {
+		log.Errorf("Failed to acquire database connection: %v", err)
+		return fmt.Errorf("mlib_repo: putTranslation: db acquire: %w", err)
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Errorf("Failed to begin transaction: %v", err)
+		return fmt.Errorf("mlib_repo: putTranslation: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var songId int
+	err = tx.QueryRow(ctx, "SELECT id FROM songs WHERE id = $1 FOR UPDATE", id).Scan(&songId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Warnf("Song ID %d does not exist", id)
+		return fmt.Errorf("mlib_repo: putTranslation: song %d: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		log.Errorf("Error locking song ID %d: %v", id, err)
+		return fmt.Errorf("mlib_repo: putTranslation: lock song: %w", err)
+	}
+
+	if original {
+		_, err = tx.Exec(ctx, "UPDATE song_lyrics SET is_original = FALSE WHERE song_id = $1 AND is_original AND language <> $2", id, language)
+		if err != nil {
+			log.Errorf("Error clearing original flag for song ID %d: %v", id, err)
+			return fmt.Errorf("mlib_repo: putTranslation: clear original: %w", err)
+		}
+	}
+
+	var isOriginal bool
+	err = tx.QueryRow(ctx, `INSERT INTO song_lyrics (song_id, language, is_original, text) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (song_id, language) DO UPDATE SET text = EXCLUDED.text, is_original = song_lyrics.is_original OR EXCLUDED.is_original
+		RETURNING is_original`, id, language, original, text).Scan(&isOriginal)
+	if err != nil {
+		log.Errorf("Error storing %s lyrics for song ID %d: %v", language, id, err)
+		return fmt.Errorf("mlib_repo: putTranslation: upsert lyrics: %w", err)
+	}
+
+	if isOriginal {
+		_, err = tx.Exec(ctx, "UPDATE songs SET text = $1, explicit = $2, profanity_count = $3 WHERE id = $4",
+			text, flags.Explicit, flags.ProfanityCount, id)
+		if err != nil {
+			log.Errorf("Error updating text of song ID %d: %v", id, err)
+			return fmt.Errorf("mlib_repo: putTranslation: update song text: %w", err)
+		}
+		if err = r.recordRevision(ctx, tx, id, text); err != nil {
+			log.Errorf("Error recording revision of song ID %d: %v", id, err)
+			return fmt.Errorf("mlib_repo: putTranslation: %w", err)
+		}
+		if err = r.queueSimilarityTx(ctx, tx, id); err != nil {
+			log.Errorf("Failed to queue similarities of song ID %d: %v", id, err)
+			return fmt.Errorf("mlib_repo: putTranslation: %w", err)
+		}
+	}
+
+	if err = r.replaceSections(ctx, tx, id, language, lyrics); err != nil {
+		log.Errorf("Error storing lyrics sections for song ID %d: %v", id, err)
+		return fmt.Errorf("mlib_repo: putTranslation: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Errorf("Transaction commit failed: %v", err)
+		return fmt.Errorf("mlib_repo: putTranslation: commit tx: %w", err)
+	}
+
+	log.Infof("Successfully stored %s lyrics for song ID: %d", language, id)
+	return nil
+}
+
+// DeleteTranslation removes a translation of a song. The original lyrics cannot be deleted.
+func (r *MLibRepository) DeleteTranslation(ctx context.Context, id int, language string) error {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("DeleteTranslation", time.Now())
+	log.Infof("DeleteTranslation called with song ID: %d, language: %s", id, language)
+
+	conn, err := r.db.Acquire(ctx)
+	if err != nil {
+		log.Errorf("Failed to acquire database connection: %v", err)
+		return fmt.Errorf("mlib_repo: deleteTranslation: db acquire: %w", err)
+	}
+	defer conn.Release()
+
+	var isOriginal bool
+	err = conn.QueryRow(ctx, "DELETE FROM song_lyrics WHERE song_id = $1 AND language = $2 AND NOT is_original RETURNING is_original",
+		id, language).Scan(&isOriginal)
+	if errors.Is(err, pgx.ErrNoRows) {
+		var exists bool
+		err = conn.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM song_lyrics WHERE song_id = $1 AND language = $2)", id, language).Scan(&exists)
+		if err != nil {
+			log.Errorf("Error checking %s lyrics of song ID %d: %v", language, id, err)
+			return fmt.Errorf("mlib_repo: deleteTranslation: select exists: %w", err)
+		}
+		if exists {
+			log.Warnf("Refusing to delete original lyrics of song ID %d", id)
+			return fmt.Errorf("mlib_repo: deleteTranslation: original lyrics cannot be deleted: %w", ErrConflict)
+		}
+		return fmt.Errorf("mlib_repo: deleteTranslation: %s lyrics of song %d: %w", language, id, ErrNotFound)
+	}
+	if err != nil {
+		log.Errorf("Error deleting %s lyrics of song ID %d: %v", language, id, err)
+		return fmt.Errorf("mlib_repo: deleteTranslation: delete: %w", err)
+	}
+
+	log.Infof("Successfully deleted %s lyrics of song ID: %d", language, id)
+	return nil
+}
+
+// GetSections returns the stored lyrics sections of a song in the given language in order.
+// It returns no sections for lyrics that have not been split yet.
+func (r *MLibRepository) GetSections(ctx context.Context, id int, language string) ([]models.LyricsSection, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("GetSections", time.Now())
+	log.Infof("Entering GetSections function for song ID: %d", id)
+
+	conn, err := r.acquireRead(ctx)
+	if err != nil {
+		log.Error("Failed to acquire DB connection:", err)
+		return nil, fmt.Errorf("mlib_repo: getSections: db acquire: %w", err)
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, getSectionsSQL, id, language)
+	if err != nil {
+		log.Error("Query execution failed:", err)
+		return nil, fmt.Errorf("mlib_repo: getSections: query: %w", err)
+	}
+	defer rows.Close()
+
+	var sections []models.LyricsSection
+	lastPosition := -1
+	for rows.Next() {
+		var position int
+		var kind models.SectionKind
+		var line models.LyricsLine
+		if err := rows.Scan(&position, &kind, &line.Text, &line.StartMs, &line.Words); err != nil {
+			log.Error("Row scanning failed:", err)
+			return nil, fmt.Errorf("mlib_repo: getSections: rows scan: %w", err)
+		}
+		if position != lastPosition {
+			sections = append(sections, models.LyricsSection{Kind: kind})
+			lastPosition = position
+		}
+		sections[len(sections)-1].Lines = append(sections[len(sections)-1].Lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		log.Error("Rows iteration failed:", err)
+		return nil, fmt.Errorf("mlib_repo: getSections: rows: %w", err)
+	}
+
+	log.Infof("Successfully fetched %d sections for song ID: %d", len(sections), id)
+	return sections, nil
+}
+
+// GetOriginalSections returns the stored sections of the original lyrics of the songs with the
+// given ids, keyed by song ID. Songs whose lyrics have not been split yet are left out.
+func (r *MLibRepository) GetOriginalSections(ctx context.Context, ids []int) (map[int][]models.LyricsSection, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("GetOriginalSections", time.Now())
+	log.Infof("Entering GetOriginalSections function for %d songs", len(ids))
+
+	conn, err := r.acquireRead(ctx)
+	if err != nil {
+		log.Error("Failed to acquire DB connection:", err)
+		return nil, fmt.Errorf("mlib_repo: getOriginalSections: db acquire: %w", err)
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, `SELECT s.song_id, s.position, s.kind, l.text, l.start_ms, l.words
+		FROM song_sections AS s
+		JOIN song_lyrics AS sl ON sl.song_id = s.song_id AND sl.language = s.language AND sl.is_original
+		JOIN song_lines AS l ON l.section_id = s.id
+		WHERE s.song_id = ANY($1)
+		ORDER BY s.song_id, s.position, l.position`, ids)
+	if err != nil {
+		log.Error("Query execution failed:", err)
+		return nil, fmt.Errorf("mlib_repo: getOriginalSections: query: %w", err)
+	}
+	defer rows.Close()
+
+	sections := make(map[int][]models.LyricsSection)
+	lastSong, lastPosition := -1, -1
+	for rows.Next() {
+		var songId, position int
+		var kind models.SectionKind
+		var line models.LyricsLine
+		if err := rows.Scan(&songId, &position, &kind, &line.Text, &line.StartMs, &line.Words); err != nil {
+			log.Error("Row scanning failed:", err)
+			return nil, fmt.Errorf("mlib_repo: getOriginalSections: rows scan: %w", err)
+		}
+		if songId != lastSong || position != lastPosition {
+			sections[songId] = append(sections[songId], models.LyricsSection{Kind: kind})
+			lastSong, lastPosition = songId, position
+		}
+		song := sections[songId]
+		song[len(song)-1].Lines = append(song[len(song)-1].Lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		log.Error("Rows iteration failed:", err)
+		return nil, fmt.Errorf("mlib_repo: getOriginalSections: rows: %w", err)
+	}
+
+	log.Infof("Successfully fetched sections of %d songs", len(sections))
+	return sections, nil
+}
+
+// saveOriginalLyrics stores text as the original lyrics of the song, keeping their language,
+// records it as a revision and replaces their sections.
+func (r *MLibRepository) saveOriginalLyrics(ctx context.Context, tx pgx.Tx, songId int, text string, lyrics models.Lyrics) error {
+	language := models.LanguageUndetermined
+	err := tx.QueryRow(ctx, "UPDATE song_lyrics SET text = $1 WHERE song_id = $2 AND is_original RETURNING language",
+		text, songId).Scan(&language)
+	if errors.Is(err, pgx.ErrNoRows) {
+		_, err = tx.Exec(ctx, "INSERT INTO song_lyrics (song_id, language, is_original, text) VALUES ($1, $2, TRUE, $3)",
+			songId, language, text)
+	}
+	if err != nil {
+		return fmt.Errorf("saveOriginalLyrics: upsert lyrics: %w", err)
+	}
+
+	if err = r.recordRevision(ctx, tx, songId, text); err != nil {
+		return fmt.Errorf("saveOriginalLyrics: %w", err)
+	}
+
+	return r.replaceSections(ctx, tx, songId, language, lyrics)
+}
+
+// replaceSections stores lyrics as the sections and lines of the song in the given language,
+// replacing any previous ones.
+func (r *MLibRepository) replaceSections(ctx context.Context, tx pgx.Tx, songId int, language string, lyrics models.Lyrics) error {
+	log := utils.LoggerFromContext(ctx, r.log)
+	log.Debugf("Replacing %d %s lyrics sections for song ID: %d", len(lyrics.Sections), language, songId)
+
+	_, err := tx.Exec(ctx, "DELETE FROM song_sections WHERE song_id = $1 AND language = $2", songId, language)
+	if err != nil {
+		return fmt.Errorf("replaceSections: delete sections: %w", err)
+	}
+
+	for position, section := range lyrics.Sections {
+		var sectionId int
+		err = tx.QueryRow(ctx, "INSERT INTO song_sections (song_id, language, position, kind) VALUES ($1, $2, $3, $4) RETURNING id",
+			songId, language, position, section.Kind).Scan(&sectionId)
+		if err != nil {
+			return fmt.Errorf("replaceSections: insert section: %w", err)
+		}
+
+		for linePosition, line := range section.Lines {
+			_, err = tx.Exec(ctx, "INSERT INTO song_lines (section_id, position, text, start_ms, words) VALUES ($1, $2, $3, $4, $5)",
+				sectionId, linePosition, line.Text, line.StartMs, line.Words)
+			if err != nil {
+				return fmt.Errorf("replaceSections: insert line: %w", err)
+			}
+		}
+	}
+	return nil
+}
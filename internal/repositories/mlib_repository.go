@@ -2,58 +2,72 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sirupsen/logrus"
+	"music-library/internal/db"
+	"music-library/internal/metrics"
 	"music-library/internal/models"
+	"music-library/internal/utils"
+	"time"
 )
 
+// MLibRepository writes to the primary database. Reads that tolerate replication lag go to the
+// replicas, if any, unless the request context requires the primary, see utils.ContextWithPrimary.
 type MLibRepository struct {
-	db  *pgxpool.Pool
-	log *logrus.Logger
+	db       *pgxpool.Pool
+	replicas *db.ReplicaSet
+	log      *logrus.Logger
 }
 
-func NewMLibRepository(db *pgxpool.Pool, log *logrus.Logger) *MLibRepository {
-	return &MLibRepository{db: db, log: log}
+func NewMLibRepository(pool *pgxpool.Pool, replicas *db.ReplicaSet, log *logrus.Logger) *MLibRepository {
+	return &MLibRepository{db: pool, replicas: replicas, log: log}
 }
 
-func (r *MLibRepository) GetLibrary(ctx context.Context, filters map[string]interface{}, page, limit int) ([]models.Song, error) {
-	r.log.Info("Entering GetLibrary function")
-	r.log.Debugf("Filters: %+v, Page: %d, Limit: %d", filters, page, limit)
+// acquireRead returns a replica connection for a read, falling back to the primary when the
+// request requires it or no replica is healthy.
+func (r *MLibRepository) acquireRead(ctx context.Context) (*pgxpool.Conn, error) {
+	if !utils.PrimaryRequired(ctx) {
+		conn, err := r.replicas.Acquire(ctx)
+		if err == nil {
+			return conn, nil
+		}
+		if !errors.Is(err, db.ErrNoHealthyReplica) {
+			return nil, err
+		}
+	}
+	return r.db.Acquire(ctx)
+}
 
-	conn, err := r.db.Acquire(ctx)
+// BeginTx starts a transaction for the methods taking a pgx.Tx. Callers must commit or roll it back.
+func (r *MLibRepository) BeginTx(ctx context.Context) (pgx.Tx, error) {
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		r.log.Error("Failed to acquire DB connection:", err)
-		return nil, err
+		return nil, fmt.Errorf("begin tx: %w", err)
 	}
-	defer conn.Release()
+	return tx, nil
+}
 
-	offset := (page - 1) * limit
-	query := `SELECT s.id, g.group_name, s.song_name, s.release_date, s.text, s.link
-		FROM songs AS s
-		JOIN groups AS g ON s.group_id = g.id`
-	args := []interface{}{}
-	index := 1
+func (r *MLibRepository) GetLibrary(ctx context.Context, filter models.LibraryFilter, page, limit int) ([]models.Song, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("GetLibrary", time.Now())
+	log.Info("Entering GetLibrary function")
 
-	for k, v := range filters {
-		if k == "g.group_name" || k == "s.song_name" || k == "s.text" {
-			query += fmt.Sprintf(" AND %s ILIKE $%d", k, index)
-		} else {
-			query += fmt.Sprintf(" AND %s = $%d", k, index)
-		}
-		args = append(args, v)
-		index++
-		r.log.Debugf("Adding filter: %s = %v", k, v)
+	conn, err := r.acquireRead(ctx)
+	if err != nil {
+		log.Error("Failed to acquire DB connection:", err)
+		return nil, err
 	}
+	defer conn.Release()
 
-	query += fmt.Sprintf("\n\tLIMIT $%d OFFSET $%d", index, index+1)
-	args = append(args, limit, offset)
-	r.log.Debugf("Query: %s", query)
+	query, args := libraryQuery(filter, page, limit)
+	log.Debugf("Query: %s, args: %v", query, args)
 
 	rows, err := conn.Query(ctx, query, args...)
 	if err != nil {
-		r.log.Error("Query execution failed:", err)
+		log.Error("Query execution failed:", err)
 		return nil, fmt.Errorf("mlib repo: getLib: query: %w", err)
 	}
 	defer rows.Close()
@@ -61,283 +75,417 @@ func (r *MLibRepository) GetLibrary(ctx context.Context, filters map[string]inte
 	var songs []models.Song
 	for rows.Next() {
 		var song models.Song
-		err := rows.Scan(&song.ID, &song.Group, &song.Song, &song.ReleaseDate, &song.Text, &song.Link)
+		err := rows.Scan(&song.ID, &song.Group, &song.Song, &song.ReleaseDate, &song.Text, &song.Link, &song.Explicit, &song.ProfanityCount)
 		if err != nil {
-			r.log.Error("Row scanning failed:", err)
+			log.Error("Row scanning failed:", err)
 			return nil, fmt.Errorf("mlib repo: getLib: rows scan: %w", err)
 		}
-		r.log.Debugf("Scanned song: %+v", song)
+		log.WithFields(utils.SongFields(song)).Debug("Scanned song")
 		songs = append(songs, song)
 	}
 
-	r.log.Infof("Successfully fetched %d songs", len(songs))
+	log.Infof("Successfully fetched %d songs", len(songs))
 	return songs, nil
 }
 
 func (r *MLibRepository) GetText(ctx context.Context, id int) (string, error) {
-	r.log.Infof("Entering GetText function for song ID: %d", id)
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("GetText", time.Now())
+	log.Infof("Entering GetText function for song ID: %d", id)
 
-	conn, err := r.db.Acquire(ctx)
+	conn, err := r.acquireRead(ctx)
 	if err != nil {
-		r.log.Error("Failed to acquire DB connection:", err)
+		log.Error("Failed to acquire DB connection:", err)
 		return "", fmt.Errorf("mlib_repo: getText: db acquire: %w", err)
 	}
 	defer conn.Release()
 
 	var text string
-	err = conn.QueryRow(ctx, "SELECT text FROM songs WHERE id=$1", id).Scan(&text)
+	err = conn.QueryRow(ctx, getTextSQL, id).Scan(&text)
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Warnf("Song ID %d does not exist", id)
+		return "", fmt.Errorf("mlib_repo: getText: %w", ErrNotFound)
+	}
 	if err != nil {
-		r.log.Error("QueryRow failed:", err)
+		log.Error("QueryRow failed:", err)
 		return "", fmt.Errorf("mlib_repo: getText: queryRow: %w", err)
 	}
 
-	r.log.Infof("Successfully fetched text for song ID: %d", id)
+	log.Infof("Successfully fetched text for song ID: %d", id)
 	return text, nil
 }
 
-func (r *MLibRepository) DeleteSong(ctx context.Context, id int) error {
-	r.log.Infof("DeleteSong called with ID: %d", id)
+// GetSong returns the song with the given ID.
+func (r *MLibRepository) GetSong(ctx context.Context, id int) (models.Song, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("GetSong", time.Now())
+	log.Infof("Entering GetSong function for song ID: %d", id)
 
 	conn, err := r.db.Acquire(ctx)
 	if err != nil {
-		r.log.Errorf("Failed to acquire database connection: %v", err)
-		return fmt.Errorf("mlib_repo: deleteSong: db acquire: %w", err)
+		log.Error("Failed to acquire DB connection:", err)
+		return models.Song{}, fmt.Errorf("mlib_repo: getSong: db acquire: %w", err)
 	}
 	defer conn.Release()
 
-	tx, err := conn.Begin(ctx)
+	song, err := selectSong(ctx, conn, id)
+	if err != nil {
+		log.Warnf("Failed to fetch song ID %d: %v", id, err)
+		return models.Song{}, fmt.Errorf("mlib_repo: %w", err)
+	}
+
+	log.WithFields(utils.SongFields(song)).Info("Successfully fetched song")
+	return song, nil
+}
+
+// querier is implemented by both pool connections and transactions.
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// selectSong reads a song through a connection or a transaction.
+func selectSong(ctx context.Context, q querier, id int) (models.Song, error) {
+	var song models.Song
+	err := q.QueryRow(ctx, selectSongSQL, id).Scan(&song.ID, &song.Group, &song.Song, &song.ReleaseDate, &song.Text, &song.Link, &song.Explicit, &song.ProfanityCount)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Song{}, fmt.Errorf("getSong: song %d: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return models.Song{}, fmt.Errorf("getSong: queryRow: %w", err)
+	}
+	return song, nil
+}
+
+func (r *MLibRepository) DeleteSong(ctx context.Context, id int) error {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("DeleteSong", time.Now())
+	log.Infof("DeleteSong called with ID: %d", id)
+
+	tx, err := r.BeginTx(ctx)
 	if err != nil {
-		r.log.Errorf("Failed to begin transaction: %v", err)
-		return fmt.Errorf("mlib_repo: deleteSong: begin tx: %w", err)
+		log.Errorf("Failed to begin transaction: %v", err)
+		return fmt.Errorf("mlib_repo: deleteSong: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
+	if err = r.DeleteSongTx(ctx, tx, id); err != nil {
+		return fmt.Errorf("mlib_repo: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Errorf("Transaction commit failed: %v", err)
+		return fmt.Errorf("mlib_repo: deleteSong: commit tx: %w", err)
+	}
+
+	log.Infof("Successfully deleted song with ID: %d", id)
+	return nil
+}
+
+// DeleteSongTx deletes the song within tx, and its group when no other song belongs to it. The
+// playlist items of the song stay behind as unavailable, and the songs it was similar to are
+// queued for their similarities to be recomputed.
+func (r *MLibRepository) DeleteSongTx(ctx context.Context, tx pgx.Tx, id int) error {
+	log := utils.LoggerFromContext(ctx, r.log)
+
 	var groupId int
-	err = tx.QueryRow(ctx, "SELECT group_id FROM songs WHERE id = $1", id).Scan(&groupId)
+	err := tx.QueryRow(ctx, "SELECT group_id FROM songs WHERE id = $1", id).Scan(&groupId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Warnf("Song ID %d does not exist", id)
+		return fmt.Errorf("deleteSong: song %d: %w", id, ErrNotFound)
+	}
 	if err != nil {
-		r.log.Errorf("Error retrieving group_id for song ID %d: %v", id, err)
-		return fmt.Errorf("mlib_repo: deleteSong: queryRow: %w", err)
+		log.Errorf("Error retrieving group_id for song ID %d: %v", id, err)
+		return fmt.Errorf("deleteSong: queryRow: %w", err)
+	}
+	log.Debugf("Retrieved group_id: %d for song ID: %d", groupId, id)
+
+	if err = r.deleteSongTagsTx(ctx, tx, id); err != nil {
+		log.Errorf("Error deleting tags of song ID %d: %v", id, err)
+		return fmt.Errorf("deleteSong: %w", err)
+	}
+
+	if err = r.markPlaylistItemsUnavailableTx(ctx, tx, id); err != nil {
+		log.Errorf("Error detaching playlist items of song ID %d: %v", id, err)
+		return fmt.Errorf("deleteSong: %w", err)
+	}
+
+	if err = r.queueSimilarSongsTx(ctx, tx, id); err != nil {
+		log.Errorf("Error queueing the songs similar to song ID %d: %v", id, err)
+		return fmt.Errorf("deleteSong: %w", err)
 	}
-	r.log.Debugf("Retrieved group_id: %d for song ID: %d", groupId, id)
 
 	_, err = tx.Exec(ctx, "DELETE FROM songs WHERE id = $1", id)
 	if err != nil {
-		r.log.Errorf("Error deleting song with ID %d: %v", id, err)
-		return fmt.Errorf("mlib_repo: deleteSong: delete song: %w", err)
+		log.Errorf("Error deleting song with ID %d: %v", id, err)
+		return fmt.Errorf("deleteSong: delete song: %w", err)
 	}
-	r.log.Debugf("Successfully deleted song with ID: %d", id)
+	log.Debugf("Successfully deleted song with ID: %d", id)
 
 	var countGroupSongs int
 	err = tx.QueryRow(ctx, "SELECT COUNT(*) FROM songs WHERE group_id = $1", groupId).Scan(&countGroupSongs)
 	if err != nil {
-		r.log.Errorf("Error counting songs for group_id %d: %v", groupId, err)
-		return fmt.Errorf("mlib_repo: deleteSong: queryRow: %w", err)
+		log.Errorf("Error counting songs for group_id %d: %v", groupId, err)
+		return fmt.Errorf("deleteSong: queryRow: %w", err)
 	}
 
 	if countGroupSongs == 0 {
 		_, err = tx.Exec(ctx, "DELETE FROM groups WHERE id = $1", groupId)
 		if err != nil {
-			r.log.Errorf("Error deleting group with ID %d: %v", groupId, err)
-			return fmt.Errorf("mlib_repo: deleteSong: delete group: %w", err)
+			log.Errorf("Error deleting group with ID %d: %v", groupId, err)
+			return fmt.Errorf("deleteSong: delete group: %w", err)
 		}
-		r.log.Debugf("Successfully deleted group with ID: %d", groupId)
+		log.Debugf("Successfully deleted group with ID: %d", groupId)
 	}
-
-	if err = tx.Commit(ctx); err != nil {
-		r.log.Errorf("Transaction commit failed: %v", err)
-		return fmt.Errorf("mlib_repo: deleteSong: commit tx: %w", err)
-	}
-
-	r.log.Infof("Successfully deleted song with ID: %d", id)
 	return nil
 }
 
-func (r *MLibRepository) editGroup(ctx context.Context, tx pgx.Tx, id int, update interface{}) error {
-	r.log.Infof("editGroup called with song ID: %d and new group: %v", id, update)
+func (r *MLibRepository) editGroup(ctx context.Context, tx pgx.Tx, id int, update string) error {
+	log := utils.LoggerFromContext(ctx, r.log)
+	log.Infof("editGroup called with song ID: %d and new group: %v", id, update)
 
 	var groupExists bool
 	err := tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM groups WHERE group_name ILIKE $1)", update).Scan(&groupExists)
 	if err != nil {
-		r.log.Errorf("Failed to check if group exists for %v: %v", update, err)
+		log.Errorf("Failed to check if group exists for %v: %v", update, err)
 		return fmt.Errorf("mlib_repo: editGroup: select exists new group: %w", err)
 	}
-	r.log.Debugf("Group existence for %v: %t", update, groupExists)
+	log.Debugf("Group existence for %v: %t", update, groupExists)
 
 	var oldGroupId int
 	err = tx.QueryRow(ctx, "SELECT group_id FROM songs WHERE id = $1", id).Scan(&oldGroupId)
 	if err != nil {
-		r.log.Errorf("Failed to retrieve old group ID for song ID %d: %v", id, err)
+		log.Errorf("Failed to retrieve old group ID for song ID %d: %v", id, err)
 		return fmt.Errorf("mlib_repo: editGroup: queryRow group_id: %w", err)
 	}
-	r.log.Debugf("Retrieved old group ID: %d for song ID: %d", oldGroupId, id)
+	log.Debugf("Retrieved old group ID: %d for song ID: %d", oldGroupId, id)
 
 	var groupSongsCount int
 	err = tx.QueryRow(ctx, "SELECT COUNT(*) FROM songs WHERE group_id = $1", oldGroupId).Scan(&groupSongsCount)
 	if err != nil {
-		r.log.Errorf("Failed to count songs in group ID %d: %v", oldGroupId, err)
+		log.Errorf("Failed to count songs in group ID %d: %v", oldGroupId, err)
 		return fmt.Errorf("mlib_repo: editGroup: queryRow count: %w", err)
 	}
-	r.log.Debugf("Number of songs in group ID %d: %d", oldGroupId, groupSongsCount)
+	log.Debugf("Number of songs in group ID %d: %d", oldGroupId, groupSongsCount)
 
 	var updatedGroupId int
 	if groupExists {
 		err = tx.QueryRow(ctx, "SELECT id FROM groups WHERE group_name ILIKE $1", update).Scan(&updatedGroupId)
 		if err != nil {
-			r.log.Errorf("Failed to retrieve ID for existing group %v: %v", update, err)
+			log.Errorf("Failed to retrieve ID for existing group %v: %v", update, err)
 			return fmt.Errorf("mlib_repo: editGroup: select id group if exists: %w", err)
 		}
-		r.log.Debugf("Retrieved group ID %d for existing group %v", updatedGroupId, update)
+		log.Debugf("Retrieved group ID %d for existing group %v", updatedGroupId, update)
 
 		_, err = tx.Exec(ctx, "UPDATE songs SET group_id = $1 WHERE id = $2", updatedGroupId, id)
 		if err != nil {
-			r.log.Errorf("Failed to update group ID in songs: %v", err)
+			log.Errorf("Failed to update group ID in songs: %v", err)
 			return fmt.Errorf("mlib_repo: editGroup: edit group_id in songs if exists: %w", err)
 		}
-		r.log.Infof("Updated group ID to %d for song ID %d", updatedGroupId, id)
+		log.Infof("Updated group ID to %d for song ID %d", updatedGroupId, id)
 
 		if groupSongsCount == 1 {
 			_, err = tx.Exec(ctx, "DELETE FROM groups WHERE id = $1", oldGroupId)
 			if err != nil {
-				r.log.Errorf("Failed to delete old group ID %d: %v", oldGroupId, err)
+				log.Errorf("Failed to delete old group ID %d: %v", oldGroupId, err)
 				return fmt.Errorf("mlib_repo: editGroup: delete old group if exists and count = 1: %w", err)
 			}
-			r.log.Infof("Deleted old group ID %d", oldGroupId)
+			log.Infof("Deleted old group ID %d", oldGroupId)
 		}
 	} else {
 		if groupSongsCount == 1 {
 			_, err = tx.Exec(ctx, "UPDATE groups SET group_name = $1 WHERE id = $2", update, oldGroupId)
 			if err != nil {
-				r.log.Errorf("Failed to update group name for ID %d: %v", oldGroupId, err)
+				log.Errorf("Failed to update group name for ID %d: %v", oldGroupId, err)
 				return fmt.Errorf("mlib_repo: editGroup: update group if !exists and count = 1: %w", err)
 			}
-			r.log.Infof("Updated group name to %v for group ID %d", update, oldGroupId)
+			log.Infof("Updated group name to %v for group ID %d", update, oldGroupId)
 			updatedGroupId = oldGroupId
 		} else {
 			err = tx.QueryRow(ctx, "INSERT INTO groups (group_name) VALUES ($1) RETURNING id", update).Scan(&updatedGroupId)
 			if err != nil {
-				r.log.Errorf("Failed to insert new group %v: %v", update, err)
+				log.Errorf("Failed to insert new group %v: %v", update, err)
 				return fmt.Errorf("mlib_repo: editGroup: insert new group if !exists and count > 1: %w", err)
 			}
-			r.log.Infof("Inserted new group %v with ID %d", update, updatedGroupId)
+			log.Infof("Inserted new group %v with ID %d", update, updatedGroupId)
 
 			_, err = tx.Exec(ctx, "UPDATE songs SET group_id = $1 WHERE id = $2", updatedGroupId, id)
 			if err != nil {
-				r.log.Errorf("Failed to update song with new group ID %d: %v", updatedGroupId, err)
+				log.Errorf("Failed to update song with new group ID %d: %v", updatedGroupId, err)
 				return fmt.Errorf("mlib_repo: editGroup: edit group_id in songs if !exists and count > 1: %w", err)
 			}
-			r.log.Infof("Updated song ID %d with new group ID %d", id, updatedGroupId)
+			log.Infof("Updated song ID %d with new group ID %d", id, updatedGroupId)
 		}
 	}
 	return nil
 }
 
-func (r *MLibRepository) EditSong(ctx context.Context, id int, updates map[string]interface{}) error {
-	r.log.Infof("EditSong called with song ID: %d and updates: %+v", id, updates)
+// EditSong applies updates to the song and returns the result. When the text changes, lyrics holds its parsed sections.
+func (r *MLibRepository) EditSong(ctx context.Context, id int, update models.SongUpdate, lyrics *models.Lyrics) (models.Song, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("EditSong", time.Now())
+	log.WithFields(utils.UpdateFields(update)).Infof("EditSong called with song ID: %d", id)
 
-	conn, err := r.db.Acquire(ctx)
+	tx, err := r.BeginTx(ctx)
 	if err != nil {
-		r.log.Errorf("Failed to acquire database connection: %v", err)
-		return fmt.Errorf("mlib_repo: editSong: db acquire: %w", err)
+		log.Errorf("Failed to begin transaction: %v", err)
+		return models.Song{}, fmt.Errorf("mlib_repo: editSong: %w", err)
 	}
-	defer conn.Release()
+	defer tx.Rollback(ctx)
 
-	tx, err := conn.Begin(ctx)
+	song, err := r.EditSongTx(ctx, tx, id, update, lyrics)
 	if err != nil {
-		r.log.Errorf("Failed to begin transaction: %v", err)
-		return fmt.Errorf("mlib_repo: editSong: begin tx: %w", err)
+		return models.Song{}, fmt.Errorf("mlib_repo: %w", err)
 	}
-	defer tx.Rollback(ctx)
 
-	if val, ok := updates["group_name"]; ok {
-		r.log.Debugf("Editing group for song ID: %d with new group name: %v", id, val)
-		err := r.editGroup(ctx, tx, id, val)
-		if err != nil {
-			r.log.Errorf("Failed to edit group for song ID %d: %v", id, err)
-			return fmt.Errorf("mlib_repo: editSong: update group: %w", err)
-		}
-		delete(updates, "group_name")
+	if err = tx.Commit(ctx); err != nil {
+		log.Errorf("Transaction commit failed for song ID %d: %v", id, err)
+		return models.Song{}, fmt.Errorf("mlib_repo: editSong: commit tx: %w", err)
 	}
+	log.Infof("Successfully edited song with ID: %d", id)
+	return song, nil
+}
 
-	if len(updates) >= 1 {
-		r.log.Debugf("Updating song fields for song ID: %d with updates: %+v", id, updates)
+// EditSongTx applies updates to the song within tx, see EditSong. The song row stays locked until tx ends.
+func (r *MLibRepository) EditSongTx(ctx context.Context, tx pgx.Tx, id int, update models.SongUpdate, lyrics *models.Lyrics) (models.Song, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
 
-		query := `UPDATE songs SET `
-		args := []interface{}{}
-		index := 1
+	var songId int
+	err := tx.QueryRow(ctx, "SELECT id FROM songs WHERE id = $1 FOR UPDATE", id).Scan(&songId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Warnf("Song ID %d does not exist", id)
+		return models.Song{}, fmt.Errorf("editSong: song %d: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		log.Errorf("Error locking song ID %d: %v", id, err)
+		return models.Song{}, fmt.Errorf("editSong: lock song: %w", err)
+	}
 
-		for k, v := range updates {
-			if index > 1 {
-				query += ", "
-			}
-			query += fmt.Sprintf("%s = $%d", k, index)
-			args = append(args, v)
-			index++
+	if update.Group != nil {
+		log.Debugf("Editing group for song ID: %d with new group name: %s", id, *update.Group)
+		err := r.editGroup(ctx, tx, id, *update.Group)
+		if err != nil {
+			log.Errorf("Failed to edit group for song ID %d: %v", id, err)
+			return models.Song{}, fmt.Errorf("editSong: update group: %w", err)
 		}
+	}
 
-		query += fmt.Sprintf(" WHERE id = $%d", index)
-		args = append(args, id)
+	if query, args, ok := songUpdateQuery(id, update); ok {
+		log.WithFields(utils.UpdateFields(update)).Debugf("Updating song fields for song ID: %d", id)
 
 		_, err = tx.Exec(ctx, query, args...)
 		if err != nil {
-			r.log.Errorf("Failed to update song fields for song ID %d: %v", id, err)
-			return fmt.Errorf("mlib_repo: editSong: update song: %w", err)
+			log.Errorf("Failed to update song fields for song ID %d: %v", id, err)
+			return models.Song{}, fmt.Errorf("editSong: update song: %w", err)
 		}
-		r.log.Infof("Successfully updated song fields for song ID %d", id)
+		log.Infof("Successfully updated song fields for song ID %d", id)
 	}
 
-	if err = tx.Commit(ctx); err != nil {
-		r.log.Errorf("Transaction commit failed for song ID %d: %v", id, err)
-		return fmt.Errorf("mlib_repo: editSong: commit tx: %w", err)
+	if lyrics != nil && update.Text != nil {
+		if err = r.saveOriginalLyrics(ctx, tx, id, *update.Text, *lyrics); err != nil {
+			log.Errorf("Failed to store lyrics sections for song ID %d: %v", id, err)
+			return models.Song{}, fmt.Errorf("editSong: %w", err)
+		}
 	}
-	r.log.Infof("Successfully edited song with ID: %d", id)
-	return nil
-}
 
-func (r *MLibRepository) AddSong(ctx context.Context, song models.Song) error {
-	r.log.Infof("AddSong called with song: %+v", song)
+	if err = r.queueSimilarityTx(ctx, tx, id); err != nil {
+		log.Errorf("Failed to queue similarities of song ID %d: %v", id, err)
+		return models.Song{}, fmt.Errorf("editSong: %w", err)
+	}
 
-	conn, err := r.db.Acquire(ctx)
+	song, err := selectSong(ctx, tx, id)
 	if err != nil {
-		r.log.Errorf("Failed to acquire database connection: %v", err)
-		return fmt.Errorf("mlib_repo: AddSong: db acquire: %w", err)
+		log.Errorf("Failed to read edited song ID %d: %v", id, err)
+		return models.Song{}, fmt.Errorf("editSong: %w", err)
 	}
-	defer conn.Release()
+	return song, nil
+}
+
+// AddSong inserts the song, creating its group if needed, and returns it as stored.
+func (r *MLibRepository) AddSong(ctx context.Context, song models.Song, lyrics models.Lyrics) (models.Song, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("AddSong", time.Now())
+	log.WithFields(utils.SongFields(song)).Info("AddSong called")
 
-	tx, err := conn.Begin(ctx)
+	tx, err := r.BeginTx(ctx)
 	if err != nil {
-		r.log.Errorf("Failed to begin transaction: %v", err)
-		return fmt.Errorf("mlib_repo: AddSong: begin tx: %w", err)
+		log.Errorf("Failed to begin transaction: %v", err)
+		return models.Song{}, fmt.Errorf("mlib_repo: AddSong: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
-	_, err = tx.Exec(ctx, "INSERT INTO groups (group_name) VALUES($1) ON CONFLICT (group_name) DO NOTHING", *song.Group)
+	added, err := r.AddSongTx(ctx, tx, song, lyrics)
+	if err != nil {
+		return models.Song{}, fmt.Errorf("mlib_repo: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Errorf("Transaction commit failed: %v", err)
+		return models.Song{}, fmt.Errorf("mlib_repo: AddSong: commit transaction: %w", err)
+	}
+
+	log.Infof("Successfully added song: %s", *song.Song)
+	return added, nil
+}
+
+// AddSongTx inserts the song within tx, see AddSong.
+func (r *MLibRepository) AddSongTx(ctx context.Context, tx pgx.Tx, song models.Song, lyrics models.Lyrics) (models.Song, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+
+	_, err := tx.Exec(ctx, "INSERT INTO groups (group_name) VALUES($1) ON CONFLICT (group_name) DO NOTHING", *song.Group)
 	if err != nil {
-		r.log.Errorf("Error inserting or updating group: %v", err)
-		return fmt.Errorf("mlib_repo: AddSong: query insert groups on conflict: %w", err)
+		log.Errorf("Error inserting or updating group: %v", err)
+		return models.Song{}, fmt.Errorf("addSong: query insert groups on conflict: %w", err)
 	}
-	r.log.Debugf("Group inserted or exists: %s", *song.Group)
+	log.Debugf("Group inserted or exists: %s", *song.Group)
 
 	var groupId int
 	err = tx.QueryRow(ctx, "SELECT id FROM groups WHERE group_name = $1", *song.Group).Scan(&groupId)
 	if err != nil {
-		r.log.Errorf("Error retrieving group_id for group_name %s: %v", *song.Group, err)
-		return fmt.Errorf("mlib_repo: AddSong: query group id from groups: %w", err)
+		log.Errorf("Error retrieving group_id for group_name %s: %v", *song.Group, err)
+		return models.Song{}, fmt.Errorf("addSong: query group id from groups: %w", err)
 	}
-	r.log.Debugf("Retrieved group_id: %d for group_name: %s", groupId, *song.Group)
-
-	_, err = tx.Exec(ctx, "INSERT INTO songs (group_id, song_name, release_date, text, link) VALUES ($1, $2, $3, $4, $5)",
-		groupId, *song.Song, *song.ReleaseDate, *song.Text, *song.Link)
+	log.Debugf("Retrieved group_id: %d for group_name: %s", groupId, *song.Group)
+
+	added := models.Song{Group: song.Group}
+	err = tx.QueryRow(ctx, `INSERT INTO songs (group_id, song_name, release_date, text, link, explicit, profanity_count)
+		VALUES ($1, $2, $3, $4, $5, COALESCE($6, FALSE), COALESCE($7, 0))
+		RETURNING id, song_name, release_date, text, link, explicit, profanity_count`,
+		groupId, *song.Song, *song.ReleaseDate, *song.Text, *song.Link, song.Explicit, song.ProfanityCount).Scan(
+		&added.ID, &added.Song, &added.ReleaseDate, &added.Text, &added.Link, &added.Explicit, &added.ProfanityCount)
 	if err != nil {
-		r.log.Errorf("Error inserting song: %v", err)
-		return fmt.Errorf("mlib_repo: AddSong: insert into songs: %w", err)
+		log.Errorf("Error inserting song: %v", err)
+		return models.Song{}, fmt.Errorf("addSong: insert into songs: %w", err)
 	}
-	r.log.Debugf("Successfully inserted song: %s", *song.Song)
+	log.Debugf("Successfully inserted song: %s", *song.Song)
 
-	if err = tx.Commit(ctx); err != nil {
-		r.log.Errorf("Transaction commit failed: %v", err)
-		return fmt.Errorf("mlib_repo: AddSong: commit transaction: %w", err)
+	if err = r.saveOriginalLyrics(ctx, tx, *added.ID, *song.Text, lyrics); err != nil {
+		log.Errorf("Error storing lyrics sections for song ID %d: %v", *added.ID, err)
+		return models.Song{}, fmt.Errorf("addSong: %w", err)
 	}
 
-	r.log.Infof("Successfully added song: %s", *song.Song)
-	return nil
+	if song.Genre != nil {
+		if err = r.addSongTagTx(ctx, tx, *added.ID, *song.Genre); err != nil {
+			log.Errorf("Error tagging song ID %d with its genre: %v", *added.ID, err)
+			return models.Song{}, fmt.Errorf("addSong: %w", err)
+		}
+	}
+
+	if err = r.queueSimilarityTx(ctx, tx, *added.ID); err != nil {
+		log.Errorf("Error queuing similarities of song ID %d: %v", *added.ID, err)
+		return models.Song{}, fmt.Errorf("addSong: %w", err)
+	}
+	return added, nil
+}
+
+// SongExistsTx reports whether the group already has a song with the given name.
+func (r *MLibRepository) SongExistsTx(ctx context.Context, tx pgx.Tx, group, song string) (bool, error) {
+	var exists bool
+	err := tx.QueryRow(ctx, `SELECT EXISTS (
+			SELECT 1 FROM songs AS s
+			JOIN groups AS g ON s.group_id = g.id
+			WHERE g.group_name = $1 AND s.song_name = $2)`, group, song).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("songExists: queryRow: %w", err)
+	}
+	return exists, nil
 }
@@ -0,0 +1,419 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"music-library/internal/metrics"
+	"music-library/internal/models"
+	"music-library/internal/utils"
+	"time"
+)
+
+const selectPlaylistSQL = `SELECT p.id, p.owner, p.name, p.description, p.created_at, p.updated_at,
+		(SELECT COUNT(*) FROM playlist_items AS pi WHERE pi.playlist_id = p.id)
+		FROM playlists AS p`
+
+// Items of deleted songs take the names copied when the song was deleted.
+const selectPlaylistItemSQL = `SELECT pi.id, pi.position, pi.song_id,
+		COALESCE(g.group_name, pi.group_name, ''), COALESCE(s.song_name, pi.song_name, ''), pi.added_at
+		FROM playlist_items AS pi
+		LEFT JOIN songs AS s ON s.id = pi.song_id
+		LEFT JOIN groups AS g ON g.id = s.group_id`
+
+func scanPlaylist(row pgx.Row) (models.Playlist, error) {
+	var p models.Playlist
+	err := row.Scan(&p.ID, &p.Owner, &p.Name, &p.Description, &p.CreatedAt, &p.UpdatedAt, &p.Length)
+	return p, err
+}
+
+func scanPlaylistItem(row pgx.Row) (models.PlaylistItem, error) {
+	var item models.PlaylistItem
+	err := row.Scan(&item.ID, &item.Position, &item.SongID, &item.Group, &item.Song, &item.AddedAt)
+	item.Available = item.SongID != nil
+	return item, err
+}
+
+// CreatePlaylist creates an empty playlist owned by owner.
+func (r *MLibRepository) CreatePlaylist(ctx context.Context, owner, name string, description *string) (models.Playlist, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("CreatePlaylist", time.Now())
+	log.Infof("CreatePlaylist called with owner: %s, name: %s", owner, name)
+
+	conn, err := r.db.Acquire(ctx)
+	if err != nil {
+		log.Errorf("Failed to acquire database connection: %v", err)
+		return models.Playlist{}, fmt.Errorf("mlib_repo: createPlaylist: db acquire: %w", err)
+	}
+	defer conn.Release()
+
+	playlist := models.Playlist{Owner: owner, Name: name, Description: description}
+	err = conn.QueryRow(ctx, `INSERT INTO playlists (owner, name, description) VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at`, owner, name, description).
+		Scan(&playlist.ID, &playlist.CreatedAt, &playlist.UpdatedAt)
+	if err != nil {
+		log.Errorf("Error inserting playlist: %v", err)
+		return models.Playlist{}, fmt.Errorf("mlib_repo: createPlaylist: insert: %w", err)
+	}
+
+	log.Infof("Successfully created playlist with ID: %d", playlist.ID)
+	return playlist, nil
+}
+
+// GetPlaylists returns the playlists of owner, the most recently changed first.
+func (r *MLibRepository) GetPlaylists(ctx context.Context, owner string) ([]models.Playlist, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("GetPlaylists", time.Now())
+	log.Infof("Entering GetPlaylists function for owner: %s", owner)
+
+	conn, err := r.acquireRead(ctx)
+	if err != nil {
+		log.Error("Failed to acquire DB connection:", err)
+		return nil, fmt.Errorf("mlib_repo: getPlaylists: db acquire: %w", err)
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, selectPlaylistSQL+`
+		WHERE p.owner = $1
+		ORDER BY p.updated_at DESC, p.id DESC`, owner)
+	if err != nil {
+		log.Error("Query execution failed:", err)
+		return nil, fmt.Errorf("mlib_repo: getPlaylists: query: %w", err)
+	}
+	playlists, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Playlist, error) {
+		return scanPlaylist(row)
+	})
+	if err != nil {
+		log.Error("Rows scanning failed:", err)
+		return nil, fmt.Errorf("mlib_repo: getPlaylists: rows: %w", err)
+	}
+
+	log.Infof("Successfully fetched %d playlists", len(playlists))
+	return playlists, nil
+}
+
+// GetPlaylist returns a playlist with the given page of its items in order.
+func (r *MLibRepository) GetPlaylist(ctx context.Context, id, page, limit int) (models.PlaylistPage, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("GetPlaylist", time.Now())
+	log.Infof("Entering GetPlaylist function for playlist ID: %d, page: %d, limit: %d", id, page, limit)
+
+	conn, err := r.acquireRead(ctx)
+	if err != nil {
+		log.Error("Failed to acquire DB connection:", err)
+		return models.PlaylistPage{}, fmt.Errorf("mlib_repo: getPlaylist: db acquire: %w", err)
+	}
+	defer conn.Release()
+
+	// A repeatable read transaction keeps the length consistent with the items.
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		log.Errorf("Failed to begin transaction: %v", err)
+		return models.PlaylistPage{}, fmt.Errorf("mlib_repo: getPlaylist: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	playlist, err := scanPlaylist(tx.QueryRow(ctx, selectPlaylistSQL+" WHERE p.id = $1", id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Warnf("Playlist ID %d does not exist", id)
+		return models.PlaylistPage{}, fmt.Errorf("mlib_repo: getPlaylist: playlist %d: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		log.Error("Query execution failed:", err)
+		return models.PlaylistPage{}, fmt.Errorf("mlib_repo: getPlaylist: queryRow: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, selectPlaylistItemSQL+`
+		WHERE pi.playlist_id = $1
+		ORDER BY pi.position
+		LIMIT $2 OFFSET $3`, id, limit, (page-1)*limit)
+	if err != nil {
+		log.Error("Query execution failed:", err)
+		return models.PlaylistPage{}, fmt.Errorf("mlib_repo: getPlaylist: query items: %w", err)
+	}
+	items, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.PlaylistItem, error) {
+		return scanPlaylistItem(row)
+	})
+	if err != nil {
+		log.Error("Rows scanning failed:", err)
+		return models.PlaylistPage{}, fmt.Errorf("mlib_repo: getPlaylist: rows: %w", err)
+	}
+	if items == nil {
+		items = []models.PlaylistItem{}
+	}
+
+	log.Infof("Successfully fetched %d items of playlist ID: %d", len(items), id)
+	return models.PlaylistPage{Playlist: playlist, Items: items}, nil
+}
+
+// UpdatePlaylist replaces the name and description of a playlist owned by owner.
+func (r *MLibRepository) UpdatePlaylist(ctx context.Context, id int, owner, name string, description *string) (models.Playlist, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("UpdatePlaylist", time.Now())
+	log.Infof("UpdatePlaylist called with playlist ID: %d", id)
+
+	tx, err := r.BeginTx(ctx)
+	if err != nil {
+		log.Errorf("Failed to begin transaction: %v", err)
+		return models.Playlist{}, fmt.Errorf("mlib_repo: updatePlaylist: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = r.lockPlaylistTx(ctx, tx, id, owner); err != nil {
+		return models.Playlist{}, fmt.Errorf("mlib_repo: updatePlaylist: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE playlists SET name = $1, description = $2 WHERE id = $3", name, description, id)
+	if err != nil {
+		log.Errorf("Error updating playlist ID %d: %v", id, err)
+		return models.Playlist{}, fmt.Errorf("mlib_repo: updatePlaylist: update: %w", err)
+	}
+
+	playlist, err := scanPlaylist(tx.QueryRow(ctx, selectPlaylistSQL+" WHERE p.id = $1", id))
+	if err != nil {
+		log.Errorf("Error retrieving playlist ID %d: %v", id, err)
+		return models.Playlist{}, fmt.Errorf("mlib_repo: updatePlaylist: queryRow: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Errorf("Transaction commit failed: %v", err)
+		return models.Playlist{}, fmt.Errorf("mlib_repo: updatePlaylist: commit transaction: %w", err)
+	}
+	log.Infof("Successfully updated playlist ID: %d", id)
+	return playlist, nil
+}
+
+// DeletePlaylist deletes a playlist owned by owner with its items.
+func (r *MLibRepository) DeletePlaylist(ctx context.Context, id int, owner string) error {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("DeletePlaylist", time.Now())
+	log.Infof("DeletePlaylist called with playlist ID: %d", id)
+
+	tx, err := r.BeginTx(ctx)
+	if err != nil {
+		log.Errorf("Failed to begin transaction: %v", err)
+		return fmt.Errorf("mlib_repo: deletePlaylist: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = r.lockPlaylistTx(ctx, tx, id, owner); err != nil {
+		return fmt.Errorf("mlib_repo: deletePlaylist: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, "DELETE FROM playlists WHERE id = $1", id); err != nil {
+		log.Errorf("Error deleting playlist ID %d: %v", id, err)
+		return fmt.Errorf("mlib_repo: deletePlaylist: delete: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Errorf("Transaction commit failed: %v", err)
+		return fmt.Errorf("mlib_repo: deletePlaylist: commit transaction: %w", err)
+	}
+	log.Infof("Successfully deleted playlist ID: %d", id)
+	return nil
+}
+
+// AddPlaylistItem adds a song to a playlist owned by owner at position, or at the end when
+// position is nil or past it, and returns the new item.
+func (r *MLibRepository) AddPlaylistItem(ctx context.Context, id int, owner string, songId int, position *int) (models.PlaylistItem, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("AddPlaylistItem", time.Now())
+	log.Infof("AddPlaylistItem called with playlist ID: %d, song ID: %d", id, songId)
+
+	tx, err := r.BeginTx(ctx)
+	if err != nil {
+		log.Errorf("Failed to begin transaction: %v", err)
+		return models.PlaylistItem{}, fmt.Errorf("mlib_repo: addPlaylistItem: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	length, err := r.lockPlaylistTx(ctx, tx, id, owner)
+	if err != nil {
+		return models.PlaylistItem{}, fmt.Errorf("mlib_repo: addPlaylistItem: %w", err)
+	}
+
+	if err = lockSongTx(ctx, tx, songId); err != nil {
+		log.Warnf("Failed to lock song ID %d: %v", songId, err)
+		return models.PlaylistItem{}, fmt.Errorf("mlib_repo: addPlaylistItem: %w", err)
+	}
+
+	at := length + 1
+	if position != nil && *position < at {
+		at = *position
+	}
+	_, err = tx.Exec(ctx, "UPDATE playlist_items SET position = position + 1 WHERE playlist_id = $1 AND position >= $2", id, at)
+	if err != nil {
+		log.Errorf("Error shifting items of playlist ID %d: %v", id, err)
+		return models.PlaylistItem{}, fmt.Errorf("mlib_repo: addPlaylistItem: shift items: %w", err)
+	}
+
+	var itemId int
+	err = tx.QueryRow(ctx, "INSERT INTO playlist_items (playlist_id, position, song_id) VALUES ($1, $2, $3) RETURNING id",
+		id, at, songId).Scan(&itemId)
+	if err != nil {
+		log.Errorf("Error inserting item into playlist ID %d: %v", id, err)
+		return models.PlaylistItem{}, fmt.Errorf("mlib_repo: addPlaylistItem: insert: %w", err)
+	}
+
+	item, err := scanPlaylistItem(tx.QueryRow(ctx, selectPlaylistItemSQL+" WHERE pi.id = $1", itemId))
+	if err != nil {
+		log.Errorf("Error retrieving item ID %d: %v", itemId, err)
+		return models.PlaylistItem{}, fmt.Errorf("mlib_repo: addPlaylistItem: queryRow: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Errorf("Transaction commit failed: %v", err)
+		return models.PlaylistItem{}, fmt.Errorf("mlib_repo: addPlaylistItem: commit transaction: %w", err)
+	}
+	log.Infof("Successfully added song ID %d to playlist ID %d at position %d", songId, id, at)
+	return item, nil
+}
+
+// RemovePlaylistItem removes an item from a playlist owned by owner, closing the gap it leaves.
+func (r *MLibRepository) RemovePlaylistItem(ctx context.Context, id int, owner string, itemId int) error {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("RemovePlaylistItem", time.Now())
+	log.Infof("RemovePlaylistItem called with playlist ID: %d, item ID: %d", id, itemId)
+
+	tx, err := r.BeginTx(ctx)
+	if err != nil {
+		log.Errorf("Failed to begin transaction: %v", err)
+		return fmt.Errorf("mlib_repo: removePlaylistItem: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = r.lockPlaylistTx(ctx, tx, id, owner); err != nil {
+		return fmt.Errorf("mlib_repo: removePlaylistItem: %w", err)
+	}
+
+	var position int
+	err = tx.QueryRow(ctx, "DELETE FROM playlist_items WHERE id = $1 AND playlist_id = $2 RETURNING position",
+		itemId, id).Scan(&position)
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Warnf("Item ID %d is not in playlist ID %d", itemId, id)
+		return fmt.Errorf("mlib_repo: removePlaylistItem: item %d of playlist %d: %w", itemId, id, ErrNotFound)
+	}
+	if err != nil {
+		log.Errorf("Error deleting item ID %d: %v", itemId, err)
+		return fmt.Errorf("mlib_repo: removePlaylistItem: delete: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE playlist_items SET position = position - 1 WHERE playlist_id = $1 AND position > $2", id, position)
+	if err != nil {
+		log.Errorf("Error shifting items of playlist ID %d: %v", id, err)
+		return fmt.Errorf("mlib_repo: removePlaylistItem: shift items: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Errorf("Transaction commit failed: %v", err)
+		return fmt.Errorf("mlib_repo: removePlaylistItem: commit transaction: %w", err)
+	}
+	log.Infof("Successfully removed item ID %d from playlist ID %d", itemId, id)
+	return nil
+}
+
+// MovePlaylistItem moves an item of a playlist owned by owner to position, or to the end when
+// position is past it, shifting the items in between, and returns the moved item.
+func (r *MLibRepository) MovePlaylistItem(ctx context.Context, id int, owner string, itemId, position int) (models.PlaylistItem, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("MovePlaylistItem", time.Now())
+	log.Infof("MovePlaylistItem called with playlist ID: %d, item ID: %d, position: %d", id, itemId, position)
+
+	tx, err := r.BeginTx(ctx)
+	if err != nil {
+		log.Errorf("Failed to begin transaction: %v", err)
+		return models.PlaylistItem{}, fmt.Errorf("mlib_repo: movePlaylistItem: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	length, err := r.lockPlaylistTx(ctx, tx, id, owner)
+	if err != nil {
+		return models.PlaylistItem{}, fmt.Errorf("mlib_repo: movePlaylistItem: %w", err)
+	}
+	if position > length {
+		position = length
+	}
+
+	var from int
+	err = tx.QueryRow(ctx, "SELECT position FROM playlist_items WHERE id = $1 AND playlist_id = $2", itemId, id).Scan(&from)
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Warnf("Item ID %d is not in playlist ID %d", itemId, id)
+		return models.PlaylistItem{}, fmt.Errorf("mlib_repo: movePlaylistItem: item %d of playlist %d: %w", itemId, id, ErrNotFound)
+	}
+	if err != nil {
+		log.Errorf("Error retrieving item ID %d: %v", itemId, err)
+		return models.PlaylistItem{}, fmt.Errorf("mlib_repo: movePlaylistItem: queryRow: %w", err)
+	}
+
+	if position != from {
+		// Moving up pushes the items from the new position down by one, moving down pulls the
+		// items up to the new position up by one. The deferred unique check sees the final order.
+		shift := `UPDATE playlist_items SET position = position + 1
+			WHERE playlist_id = $1 AND position >= $2 AND position < $3`
+		if position > from {
+			shift = `UPDATE playlist_items SET position = position - 1
+			WHERE playlist_id = $1 AND position <= $2 AND position > $3`
+		}
+		if _, err = tx.Exec(ctx, shift, id, position, from); err != nil {
+			log.Errorf("Error shifting items of playlist ID %d: %v", id, err)
+			return models.PlaylistItem{}, fmt.Errorf("mlib_repo: movePlaylistItem: shift items: %w", err)
+		}
+		if _, err = tx.Exec(ctx, "UPDATE playlist_items SET position = $1 WHERE id = $2", position, itemId); err != nil {
+			log.Errorf("Error moving item ID %d: %v", itemId, err)
+			return models.PlaylistItem{}, fmt.Errorf("mlib_repo: movePlaylistItem: update: %w", err)
+		}
+	}
+
+	item, err := scanPlaylistItem(tx.QueryRow(ctx, selectPlaylistItemSQL+" WHERE pi.id = $1", itemId))
+	if err != nil {
+		log.Errorf("Error retrieving item ID %d: %v", itemId, err)
+		return models.PlaylistItem{}, fmt.Errorf("mlib_repo: movePlaylistItem: queryRow: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Errorf("Transaction commit failed: %v", err)
+		return models.PlaylistItem{}, fmt.Errorf("mlib_repo: movePlaylistItem: commit transaction: %w", err)
+	}
+	log.Infof("Successfully moved item ID %d of playlist ID %d from position %d to %d", itemId, id, from, position)
+	return item, nil
+}
+
+// lockPlaylistTx locks a playlist within tx before a change, marking it as updated, and returns
+// its number of items. Changes to one playlist are applied one at a time, so its positions stay
+// gapless. Only owner may change the playlist.
+func (r *MLibRepository) lockPlaylistTx(ctx context.Context, tx pgx.Tx, id int, owner string) (int, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+
+	var playlistOwner string
+	var length int
+	err := tx.QueryRow(ctx, `UPDATE playlists SET updated_at = now() WHERE id = $1
+		RETURNING owner, (SELECT COUNT(*) FROM playlist_items WHERE playlist_id = $1)`, id).Scan(&playlistOwner, &length)
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Warnf("Playlist ID %d does not exist", id)
+		return 0, fmt.Errorf("playlist %d: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		log.Errorf("Error locking playlist ID %d: %v", id, err)
+		return 0, fmt.Errorf("lock playlist: %w", err)
+	}
+	if playlistOwner != owner {
+		log.Warnf("Playlist ID %d is not owned by %s", id, owner)
+		return 0, fmt.Errorf("playlist %d: %w", id, ErrForbidden)
+	}
+	return length, nil
+}
+
+// markPlaylistItemsUnavailableTx detaches the playlist items of the song about to be deleted,
+// keeping its names so that they can still be shown.
+func (r *MLibRepository) markPlaylistItemsUnavailableTx(ctx context.Context, tx pgx.Tx, id int) error {
+	_, err := tx.Exec(ctx, `UPDATE playlist_items AS pi
+		SET song_id = NULL, group_name = g.group_name, song_name = s.song_name
+		FROM songs AS s
+		JOIN groups AS g ON g.id = s.group_id
+		WHERE s.id = $1 AND pi.song_id = s.id`, id)
+	if err != nil {
+		return fmt.Errorf("mark playlist items unavailable: %w", err)
+	}
+	return nil
+}
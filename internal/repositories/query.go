@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"music-library/internal/models"
+	"strconv"
+	"strings"
+)
+
+// column is a column name written into generated SQL. Only the constants below exist, so no
+// caller input ever ends up in the SQL text.
+type column string
+
+// Columns filtered by libraryQuery.
+const (
+	colSongID      column = "s.id"
+	colGroupName   column = "g.group_name"
+	colSongName    column = "s.song_name"
+	colReleaseDate column = "s.release_date"
+	colText        column = "s.text"
+	colLink        column = "s.link"
+	colExplicit    column = "s.explicit"
+)
+
+// Columns assigned by songUpdateQuery, which does not alias the table.
+const (
+	setSongName       column = "song_name"
+	setReleaseDate    column = "release_date"
+	setText           column = "text"
+	setLink           column = "link"
+	setExplicit       column = "explicit"
+	setProfanityCount column = "profanity_count"
+)
+
+// taggedSongsSQL selects the songs carrying any of the tags in a text[] argument.
+const taggedSongsSQL = "SELECT st.song_id FROM song_tags AS st JOIN tags AS t ON st.tag_id = t.id WHERE t.name = ANY("
+
+const librarySQL = `SELECT s.id, g.group_name, s.song_name, s.release_date, s.text, s.link, s.explicit, s.profanity_count
+		FROM songs AS s
+		JOIN groups AS g ON s.group_id = g.id`
+
+// popularityJoinSQL adds the play counts to librarySQL. Songs never played have no row.
+const popularityJoinSQL = "\n\t\tLEFT JOIN song_popularity AS sp ON sp.song_id = s.id"
+
+// queryBuilder collects query arguments, numbering their placeholders in the order they are added.
+type queryBuilder struct {
+	args []interface{}
+}
+
+// arg adds an argument and returns its placeholder.
+func (b *queryBuilder) arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+// libraryQuery builds the query for a page of the library matching filter. Conditions always
+// come in the same order, so equal filter combinations produce equal SQL and share one plan.
+// Text filters match a case-insensitive substring, taking LIKE wildcards literally. Tags must be
+// normalized and unique, so that matching all of them means matching as many as there are.
+// Songs come in id order, or with Sort "popular" the most played first.
+func libraryQuery(filter models.LibraryFilter, page, limit int) (string, []interface{}) {
+	b := &queryBuilder{}
+	var where []string
+	cond := func(col column, op string, value interface{}) {
+		where = append(where, string(col)+" "+op+" "+b.arg(value))
+	}
+	contains := func(col column, value string) {
+		cond(col, "ILIKE", "%"+likeEscaper.Replace(value)+"%")
+	}
+
+	if filter.ID != nil {
+		cond(colSongID, "=", *filter.ID)
+	}
+	if filter.Group != nil {
+		contains(colGroupName, *filter.Group)
+	}
+	if filter.Song != nil {
+		contains(colSongName, *filter.Song)
+	}
+	if filter.ReleaseDate != nil {
+		cond(colReleaseDate, "=", *filter.ReleaseDate)
+	}
+	if filter.Text != nil {
+		contains(colText, *filter.Text)
+	}
+	if filter.Link != nil {
+		cond(colLink, "=", *filter.Link)
+	}
+	if filter.ExcludeExplicit != nil && *filter.ExcludeExplicit {
+		cond(colExplicit, "=", false)
+	}
+	if len(filter.Tags) > 0 {
+		tagged := taggedSongsSQL + b.arg(filter.Tags) + ")"
+		if filter.TagMatch == models.TagMatchAll {
+			tagged += " GROUP BY st.song_id HAVING COUNT(*) = " + b.arg(len(filter.Tags))
+		}
+		where = append(where, string(colSongID)+" IN ("+tagged+")")
+	}
+
+	query := librarySQL
+	if filter.Sort == models.SortPopular {
+		query += popularityJoinSQL
+	}
+	if len(where) > 0 {
+		query += "\n\t\tWHERE " + strings.Join(where, " AND ")
+	}
+	if filter.Sort == models.SortPopular {
+		query += "\n\t\tORDER BY COALESCE(sp.plays, 0) DESC, s.id"
+	} else {
+		query += "\n\t\tORDER BY s.id"
+	}
+	query += "\n\t\tLIMIT " + b.arg(limit) + " OFFSET " + b.arg((page-1)*limit)
+	return query, b.args
+}
+
+// songUpdateQuery builds the UPDATE of the songs columns changed by update, in a fixed order.
+// The group lives in another table and is left to editGroup. ok is false when no songs column
+// changes.
+func songUpdateQuery(id int, update models.SongUpdate) (query string, args []interface{}, ok bool) {
+	b := &queryBuilder{}
+	var set []string
+	assign := func(col column, value interface{}) {
+		set = append(set, string(col)+" = "+b.arg(value))
+	}
+
+	if update.Song != nil {
+		assign(setSongName, *update.Song)
+	}
+	if update.ReleaseDate != nil {
+		assign(setReleaseDate, *update.ReleaseDate)
+	}
+	if update.Text != nil {
+		assign(setText, *update.Text)
+	}
+	if update.Link != nil {
+		assign(setLink, *update.Link)
+	}
+	if update.Explicit != nil {
+		assign(setExplicit, *update.Explicit)
+	}
+	if update.ProfanityCount != nil {
+		assign(setProfanityCount, *update.ProfanityCount)
+	}
+	if len(set) == 0 {
+		return "", nil, false
+	}
+
+	query = "UPDATE songs SET " + strings.Join(set, ", ") + " WHERE id = " + b.arg(id)
+	return query, b.args, true
+}
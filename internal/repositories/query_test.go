@@ -0,0 +1,293 @@
+package repositories
+
+import (
+	"fmt"
+	"music-library/internal/models"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+var releaseDate = time.Date(1968, time.August, 26, 0, 0, 0, 0, time.UTC)
+
+// libraryFilterFields lists every library filter with the condition and argument it adds,
+// in the order libraryQuery writes them.
+var libraryFilterFields = []struct {
+	name string
+	set  func(*models.LibraryFilter)
+	cond string
+	arg  interface{}
+}{
+	{"id", func(f *models.LibraryFilter) { f.ID = ptr(7) }, "s.id = $%d", 7},
+	{"group", func(f *models.LibraryFilter) { f.Group = ptr("Beatles") }, "g.group_name ILIKE $%d", "%Beatles%"},
+	{"song", func(f *models.LibraryFilter) { f.Song = ptr("Jude") }, "s.song_name ILIKE $%d", "%Jude%"},
+	{"releaseDate", func(f *models.LibraryFilter) { f.ReleaseDate = ptr(releaseDate) }, "s.release_date = $%d", releaseDate},
+	{"text", func(f *models.LibraryFilter) { f.Text = ptr("sad song") }, "s.text ILIKE $%d", "%sad song%"},
+	{"link", func(f *models.LibraryFilter) { f.Link = ptr("https://example.com/heyjude") }, "s.link = $%d", "https://example.com/heyjude"},
+	{"excludeExplicit", func(f *models.LibraryFilter) { f.ExcludeExplicit = ptr(true) }, "s.explicit = $%d", false},
+	{"tags", func(f *models.LibraryFilter) { f.Tags = []string{"70s", "rock"} }, "s.id IN (" + taggedSongsSQL + "$%d))", []string{"70s", "rock"}},
+}
+
+func TestLibraryQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   models.LibraryFilter
+		page     int
+		limit    int
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "no filters",
+			page:     1,
+			limit:    10,
+			wantSQL:  librarySQL + "\n\t\tORDER BY s.id\n\t\tLIMIT $1 OFFSET $2",
+			wantArgs: []interface{}{10, 0},
+		},
+		{
+			name:     "offset from page",
+			page:     3,
+			limit:    20,
+			wantSQL:  librarySQL + "\n\t\tORDER BY s.id\n\t\tLIMIT $1 OFFSET $2",
+			wantArgs: []interface{}{20, 40},
+		},
+		{
+			name:     "single filter",
+			filter:   models.LibraryFilter{Group: ptr("Queen")},
+			page:     1,
+			limit:    10,
+			wantSQL:  librarySQL + "\n\t\tWHERE g.group_name ILIKE $1\n\t\tORDER BY s.id\n\t\tLIMIT $2 OFFSET $3",
+			wantArgs: []interface{}{"%Queen%", 10, 0},
+		},
+		{
+			name:     "wildcards are literal",
+			filter:   models.LibraryFilter{Song: ptr(`100%_\`)},
+			page:     1,
+			limit:    10,
+			wantSQL:  librarySQL + "\n\t\tWHERE s.song_name ILIKE $1\n\t\tORDER BY s.id\n\t\tLIMIT $2 OFFSET $3",
+			wantArgs: []interface{}{`%100\%\_\\%`, 10, 0},
+		},
+		{
+			name:     "explicit songs not excluded",
+			filter:   models.LibraryFilter{ExcludeExplicit: ptr(false)},
+			page:     1,
+			limit:    10,
+			wantSQL:  librarySQL + "\n\t\tORDER BY s.id\n\t\tLIMIT $1 OFFSET $2",
+			wantArgs: []interface{}{10, 0},
+		},
+		{
+			name:     "any tag",
+			filter:   models.LibraryFilter{Tags: []string{"70s", "rock"}, TagMatch: models.TagMatchAny},
+			page:     1,
+			limit:    10,
+			wantSQL:  librarySQL + "\n\t\tWHERE s.id IN (" + taggedSongsSQL + "$1))\n\t\tORDER BY s.id\n\t\tLIMIT $2 OFFSET $3",
+			wantArgs: []interface{}{[]string{"70s", "rock"}, 10, 0},
+		},
+		{
+			name:     "all tags",
+			filter:   models.LibraryFilter{Group: ptr("Queen"), Tags: []string{"70s", "rock"}, TagMatch: models.TagMatchAll},
+			page:     1,
+			limit:    10,
+			wantSQL:  librarySQL + "\n\t\tWHERE g.group_name ILIKE $1 AND s.id IN (" + taggedSongsSQL + "$2) GROUP BY st.song_id HAVING COUNT(*) = $3)\n\t\tORDER BY s.id\n\t\tLIMIT $4 OFFSET $5",
+			wantArgs: []interface{}{"%Queen%", []string{"70s", "rock"}, 2, 10, 0},
+		},
+		{
+			name:     "popular first",
+			filter:   models.LibraryFilter{Sort: models.SortPopular},
+			page:     1,
+			limit:    10,
+			wantSQL:  librarySQL + popularityJoinSQL + "\n\t\tORDER BY COALESCE(sp.plays, 0) DESC, s.id\n\t\tLIMIT $1 OFFSET $2",
+			wantArgs: []interface{}{10, 0},
+		},
+		{
+			name:     "popular with filter",
+			filter:   models.LibraryFilter{Group: ptr("Queen"), Sort: models.SortPopular},
+			page:     2,
+			limit:    5,
+			wantSQL:  librarySQL + popularityJoinSQL + "\n\t\tWHERE g.group_name ILIKE $1\n\t\tORDER BY COALESCE(sp.plays, 0) DESC, s.id\n\t\tLIMIT $2 OFFSET $3",
+			wantArgs: []interface{}{"%Queen%", 5, 5},
+		},
+		{
+			name:     "sort by id",
+			filter:   models.LibraryFilter{Sort: models.SortID},
+			page:     1,
+			limit:    10,
+			wantSQL:  librarySQL + "\n\t\tORDER BY s.id\n\t\tLIMIT $1 OFFSET $2",
+			wantArgs: []interface{}{10, 0},
+		},
+		{
+			name:     "filter values stay out of the SQL",
+			filter:   models.LibraryFilter{Link: ptr("x' OR 1=1 --")},
+			page:     1,
+			limit:    10,
+			wantSQL:  librarySQL + "\n\t\tWHERE s.link = $1\n\t\tORDER BY s.id\n\t\tLIMIT $2 OFFSET $3",
+			wantArgs: []interface{}{"x' OR 1=1 --", 10, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSQL, gotArgs := libraryQuery(tt.filter, tt.page, tt.limit)
+			if gotSQL != tt.wantSQL {
+				t.Errorf("SQL = %q, want %q", gotSQL, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+				t.Errorf("args = %#v, want %#v", gotArgs, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestLibraryQueryCombinations(t *testing.T) {
+	for mask := 0; mask < 1<<len(libraryFilterFields); mask++ {
+		var filter models.LibraryFilter
+		var names, conds []string
+		var wantArgs []interface{}
+		for i, field := range libraryFilterFields {
+			if mask&(1<<i) == 0 {
+				continue
+			}
+			field.set(&filter)
+			names = append(names, field.name)
+			wantArgs = append(wantArgs, field.arg)
+			conds = append(conds, fmt.Sprintf(field.cond, len(wantArgs)))
+		}
+
+		wantSQL := librarySQL
+		if len(conds) > 0 {
+			wantSQL += "\n\t\tWHERE " + strings.Join(conds, " AND ")
+		}
+		wantSQL += fmt.Sprintf("\n\t\tORDER BY s.id\n\t\tLIMIT $%d OFFSET $%d", len(wantArgs)+1, len(wantArgs)+2)
+		wantArgs = append(wantArgs, 5, 10)
+
+		t.Run(subtestName(names), func(t *testing.T) {
+			for run := 0; run < 2; run++ {
+				gotSQL, gotArgs := libraryQuery(filter, 3, 5)
+				if gotSQL != wantSQL {
+					t.Fatalf("SQL = %q, want %q", gotSQL, wantSQL)
+				}
+				if !reflect.DeepEqual(gotArgs, wantArgs) {
+					t.Fatalf("args = %#v, want %#v", gotArgs, wantArgs)
+				}
+			}
+		})
+	}
+}
+
+// songUpdateFields lists every song update field with the assignment and argument it adds, in
+// the order songUpdateQuery writes them. The group is not a songs column and adds nothing.
+var songUpdateFields = []struct {
+	name   string
+	set    func(*models.SongUpdate)
+	assign string
+	arg    interface{}
+}{
+	{"group", func(u *models.SongUpdate) { u.Group = ptr("Queen") }, "", nil},
+	{"song", func(u *models.SongUpdate) { u.Song = ptr("Hey Jude") }, "song_name = $%d", "Hey Jude"},
+	{"releaseDate", func(u *models.SongUpdate) { u.ReleaseDate = ptr(releaseDate) }, "release_date = $%d", releaseDate},
+	{"text", func(u *models.SongUpdate) { u.Text = ptr("Hey, Jude") }, "text = $%d", "Hey, Jude"},
+	{"link", func(u *models.SongUpdate) { u.Link = ptr("https://example.com/heyjude") }, "link = $%d", "https://example.com/heyjude"},
+	{"explicit", func(u *models.SongUpdate) { u.Explicit = ptr(true) }, "explicit = $%d", true},
+	{"profanityCount", func(u *models.SongUpdate) { u.ProfanityCount = ptr(2) }, "profanity_count = $%d", 2},
+}
+
+func TestSongUpdateQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		update   models.SongUpdate
+		wantSQL  string
+		wantArgs []interface{}
+		wantOK   bool
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:   "group only",
+			update: models.SongUpdate{Group: ptr("Queen")},
+		},
+		{
+			name:     "text with content flags",
+			update:   models.SongUpdate{Text: ptr("la la"), Explicit: ptr(false), ProfanityCount: ptr(0)},
+			wantSQL:  "UPDATE songs SET text = $1, explicit = $2, profanity_count = $3 WHERE id = $4",
+			wantArgs: []interface{}{"la la", false, 0, 42},
+			wantOK:   true,
+		},
+		{
+			name:     "values stay out of the SQL",
+			update:   models.SongUpdate{Song: ptr("x'; DROP TABLE songs; --")},
+			wantSQL:  "UPDATE songs SET song_name = $1 WHERE id = $2",
+			wantArgs: []interface{}{"x'; DROP TABLE songs; --", 42},
+			wantOK:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSQL, gotArgs, gotOK := songUpdateQuery(42, tt.update)
+			if gotOK != tt.wantOK {
+				t.Fatalf("ok = %t, want %t", gotOK, tt.wantOK)
+			}
+			if gotSQL != tt.wantSQL {
+				t.Errorf("SQL = %q, want %q", gotSQL, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+				t.Errorf("args = %#v, want %#v", gotArgs, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestSongUpdateQueryCombinations(t *testing.T) {
+	for mask := 0; mask < 1<<len(songUpdateFields); mask++ {
+		var update models.SongUpdate
+		var names, assigns []string
+		var wantArgs []interface{}
+		for i, field := range songUpdateFields {
+			if mask&(1<<i) == 0 {
+				continue
+			}
+			field.set(&update)
+			names = append(names, field.name)
+			if field.assign != "" {
+				wantArgs = append(wantArgs, field.arg)
+				assigns = append(assigns, fmt.Sprintf(field.assign, len(wantArgs)))
+			}
+		}
+
+		wantOK := len(assigns) > 0
+		var wantSQL string
+		if wantOK {
+			wantSQL = fmt.Sprintf("UPDATE songs SET %s WHERE id = $%d", strings.Join(assigns, ", "), len(wantArgs)+1)
+			wantArgs = append(wantArgs, 42)
+		}
+
+		t.Run(subtestName(names), func(t *testing.T) {
+			gotSQL, gotArgs, gotOK := songUpdateQuery(42, update)
+			if gotOK != wantOK {
+				t.Fatalf("ok = %t, want %t", gotOK, wantOK)
+			}
+			if gotSQL != wantSQL {
+				t.Fatalf("SQL = %q, want %q", gotSQL, wantSQL)
+			}
+			if !reflect.DeepEqual(gotArgs, wantArgs) {
+				t.Fatalf("args = %#v, want %#v", gotArgs, wantArgs)
+			}
+			if update.Empty() != (mask == 0) {
+				t.Fatalf("Empty() = %t, want %t", update.Empty(), mask == 0)
+			}
+		})
+	}
+}
+
+func subtestName(fields []string) string {
+	if len(fields) == 0 {
+		return "none"
+	}
+	return strings.Join(fields, "+")
+}
@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"music-library/internal/metrics"
+	"music-library/internal/models"
+	"music-library/internal/utils"
+	"time"
+)
+
+// GetRevision returns a stored revision of the original lyrics of a song, the latest when
+// revision is nil.
+func (r *MLibRepository) GetRevision(ctx context.Context, id int, revision *int) (models.SongRevision, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("GetRevision", time.Now())
+	log.Infof("Entering GetRevision function for song ID: %d", id)
+
+	conn, err := r.db.Acquire(ctx)
+	if err != nil {
+		log.Error("Failed to acquire DB connection:", err)
+		return models.SongRevision{}, fmt.Errorf("mlib_repo: getRevision: db acquire: %w", err)
+	}
+	defer conn.Release()
+
+	var result models.SongRevision
+	err = conn.QueryRow(ctx, `SELECT revision, author, created_at, text
+		FROM song_revisions
+		WHERE song_id = $1 AND ($2::int IS NULL OR revision = $2)
+		ORDER BY revision DESC
+		LIMIT 1`, id, revision).Scan(&result.Revision, &result.Author, &result.CreatedAt, &result.Text)
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Warnf("No such revision for song ID: %d", id)
+		return models.SongRevision{}, fmt.Errorf("mlib_repo: getRevision: song %d: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		log.Error("QueryRow failed:", err)
+		return models.SongRevision{}, fmt.Errorf("mlib_repo: getRevision: queryRow: %w", err)
+	}
+
+	log.Infof("Successfully fetched revision %d for song ID: %d", result.Revision, id)
+	return result, nil
+}
+
+// GetRevisionHistory returns a page of the stored revisions of the original lyrics of a song,
+// newest first, followed by the revision before the page when there is one, so that every
+// revision of the page can be compared with the one it replaced.
+func (r *MLibRepository) GetRevisionHistory(ctx context.Context, id, page, limit int) ([]models.SongRevision, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("GetRevisionHistory", time.Now())
+	log.Infof("Entering GetRevisionHistory function for song ID: %d", id)
+
+	conn, err := r.db.Acquire(ctx)
+	if err != nil {
+		log.Error("Failed to acquire DB connection:", err)
+		return nil, fmt.Errorf("mlib_repo: getRevisionHistory: db acquire: %w", err)
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, `SELECT revision, author, created_at, text
+		FROM song_revisions
+		WHERE song_id = $1
+		ORDER BY revision DESC
+		LIMIT $2 OFFSET $3`, id, limit+1, (page-1)*limit)
+	if err != nil {
+		log.Error("Query execution failed:", err)
+		return nil, fmt.Errorf("mlib_repo: getRevisionHistory: query: %w", err)
+	}
+	revisions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.SongRevision, error) {
+		var revision models.SongRevision
+		err := row.Scan(&revision.Revision, &revision.Author, &revision.CreatedAt, &revision.Text)
+		return revision, err
+	})
+	if err != nil {
+		log.Error("Rows scanning failed:", err)
+		return nil, fmt.Errorf("mlib_repo: getRevisionHistory: rows: %w", err)
+	}
+
+	// An empty page past the last revision is told apart from a song without revisions.
+	if len(revisions) == 0 {
+		var exists bool
+		err = conn.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM song_revisions WHERE song_id = $1)", id).Scan(&exists)
+		if err != nil {
+			log.Error("QueryRow failed:", err)
+			return nil, fmt.Errorf("mlib_repo: getRevisionHistory: queryRow: %w", err)
+		}
+		if !exists {
+			log.Warnf("No revisions for song ID: %d", id)
+			return nil, fmt.Errorf("mlib_repo: getRevisionHistory: song %d: %w", id, ErrNotFound)
+		}
+	}
+
+	log.Infof("Successfully fetched %d revisions for song ID: %d", len(revisions), id)
+	return revisions, nil
+}
+
+// recordRevision stores text as a new revision of the original lyrics of the song, attributed to the
+// user making the request. Nothing is stored if the text equals the latest revision.
+func (r *MLibRepository) recordRevision(ctx context.Context, tx pgx.Tx, songId int, text string) error {
+	var latest int
+	var latestText *string
+	err := tx.QueryRow(ctx, `SELECT revision, text FROM song_revisions
+		WHERE song_id = $1
+		ORDER BY revision DESC
+		LIMIT 1`, songId).Scan(&latest, &latestText)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("recordRevision: select latest: %w", err)
+	}
+	if latestText != nil && *latestText == text {
+		return nil
+	}
+
+	_, err = tx.Exec(ctx, "INSERT INTO song_revisions (song_id, revision, text, author) VALUES ($1, $2, $3, $4)",
+		songId, latest+1, text, utils.UserFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("recordRevision: insert revision: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"music-library/internal/metrics"
+	"music-library/internal/models"
+	"music-library/internal/utils"
+	"strings"
+	"time"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchText returns a page of songs whose original lyrics contain phrase, ignoring case.
+// Only the ID and text of the songs are set.
+func (r *MLibRepository) SearchText(ctx context.Context, phrase string, page, limit int) ([]models.Song, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("SearchText", time.Now())
+	log.Infof("Entering SearchText function, page: %d, limit: %d", page, limit)
+
+	conn, err := r.acquireRead(ctx)
+	if err != nil {
+		log.Error("Failed to acquire DB connection:", err)
+		return nil, fmt.Errorf("mlib_repo: searchText: db acquire: %w", err)
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, `SELECT id, text
+		FROM songs
+		WHERE text ILIKE $1
+		ORDER BY id
+		LIMIT $2 OFFSET $3`, "%"+likeEscaper.Replace(phrase)+"%", limit, (page-1)*limit)
+	if err != nil {
+		log.Error("Query execution failed:", err)
+		return nil, fmt.Errorf("mlib_repo: searchText: query: %w", err)
+	}
+	defer rows.Close()
+
+	var songs []models.Song
+	for rows.Next() {
+		var song models.Song
+		if err := rows.Scan(&song.ID, &song.Text); err != nil {
+			log.Error("Row scanning failed:", err)
+			return nil, fmt.Errorf("mlib_repo: searchText: rows scan: %w", err)
+		}
+		songs = append(songs, song)
+	}
+	if err := rows.Err(); err != nil {
+		log.Error("Rows iteration failed:", err)
+		return nil, fmt.Errorf("mlib_repo: searchText: rows: %w", err)
+	}
+
+	log.Infof("Successfully found %d songs", len(songs))
+	return songs, nil
+}
@@ -0,0 +1,246 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"music-library/internal/metrics"
+	"music-library/internal/models"
+	"music-library/internal/utils"
+	"time"
+)
+
+// similarityLock names the advisory lock held while similarities are saved, so that jobs of
+// several instances do not deadlock trimming the same lists.
+const similarityLock = "music-library/similarities"
+
+// GetSimilarSongs returns up to top of the stored songs most similar to a song, the most
+// similar first.
+func (r *MLibRepository) GetSimilarSongs(ctx context.Context, id, top int) ([]models.SimilarSong, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("GetSimilarSongs", time.Now())
+	log.Infof("Entering GetSimilarSongs function for song ID: %d", id)
+
+	conn, err := r.acquireRead(ctx)
+	if err != nil {
+		log.Error("Failed to acquire DB connection:", err)
+		return nil, fmt.Errorf("mlib_repo: getSimilarSongs: db acquire: %w", err)
+	}
+	defer conn.Release()
+
+	var exists bool
+	if err = conn.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM songs WHERE id = $1)", id).Scan(&exists); err != nil {
+		log.Error("QueryRow failed:", err)
+		return nil, fmt.Errorf("mlib_repo: getSimilarSongs: queryRow: %w", err)
+	}
+	if !exists {
+		log.Warnf("Song ID %d does not exist", id)
+		return nil, fmt.Errorf("mlib_repo: getSimilarSongs: song %d: %w", id, ErrNotFound)
+	}
+
+	rows, err := conn.Query(ctx, `SELECT s.id, g.group_name, s.song_name, ss.score, ss.same_group, ss.shared_tags,
+			ss.year_gap, ss.lyrics_similarity
+		FROM song_similarities AS ss
+		JOIN songs AS s ON s.id = ss.similar_id
+		JOIN groups AS g ON g.id = s.group_id
+		WHERE ss.song_id = $1
+		ORDER BY ss.score DESC, ss.similar_id
+		LIMIT $2`, id, top)
+	if err != nil {
+		log.Error("Query execution failed:", err)
+		return nil, fmt.Errorf("mlib_repo: getSimilarSongs: query: %w", err)
+	}
+	songs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.SimilarSong, error) {
+		var song models.SimilarSong
+		var score, lyrics float32
+		err := row.Scan(&song.SongID, &song.Group, &song.Song, &score, &song.SameGroup, &song.SharedTags,
+			&song.YearGap, &lyrics)
+		song.Score, song.LyricsSimilarity = float64(score), float64(lyrics)
+		return song, err
+	})
+	if err != nil {
+		log.Error("Rows scanning failed:", err)
+		return nil, fmt.Errorf("mlib_repo: getSimilarSongs: rows: %w", err)
+	}
+
+	log.Infof("Successfully fetched %d similar songs for song ID: %d", len(songs), id)
+	return songs, nil
+}
+
+// GetSimilarityWork returns the songs queued for their similarities to be recomputed, the
+// longest queued first, with the features of every song they are compared with. Both are read
+// from the primary in one snapshot, so that every queued change is in the features and a song
+// queued again later stays queued for the next run. The features are only read when songs are
+// queued.
+func (r *MLibRepository) GetSimilarityWork(ctx context.Context) ([]models.SimilarityJob, []models.SongFeatures, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("GetSimilarityWork", time.Now())
+
+	conn, err := r.db.Acquire(ctx)
+	if err != nil {
+		log.Error("Failed to acquire DB connection:", err)
+		return nil, nil, fmt.Errorf("mlib_repo: getSimilarityWork: db acquire: %w", err)
+	}
+	defer conn.Release()
+
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		log.Errorf("Failed to begin transaction: %v", err)
+		return nil, nil, fmt.Errorf("mlib_repo: getSimilarityWork: begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, "SELECT song_id, queued_at FROM song_similarity_queue ORDER BY queued_at, song_id")
+	if err != nil {
+		log.Error("Query execution failed:", err)
+		return nil, nil, fmt.Errorf("mlib_repo: getSimilarityWork: jobs query: %w", err)
+	}
+	jobs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.SimilarityJob, error) {
+		var job models.SimilarityJob
+		err := row.Scan(&job.SongID, &job.QueuedAt)
+		return job, err
+	})
+	if err != nil {
+		log.Error("Rows scanning failed:", err)
+		return nil, nil, fmt.Errorf("mlib_repo: getSimilarityWork: jobs rows: %w", err)
+	}
+	if len(jobs) == 0 {
+		return nil, nil, nil
+	}
+
+	rows, err = tx.Query(ctx, `SELECT s.id, s.group_id, s.release_date, s.text,
+			COALESCE(array_agg(t.name) FILTER (WHERE t.name IS NOT NULL), '{}')
+		FROM songs AS s
+		LEFT JOIN song_tags AS st ON st.song_id = s.id
+		LEFT JOIN tags AS t ON t.id = st.tag_id
+		GROUP BY s.id
+		ORDER BY s.id`)
+	if err != nil {
+		log.Error("Query execution failed:", err)
+		return nil, nil, fmt.Errorf("mlib_repo: getSimilarityWork: features query: %w", err)
+	}
+	songs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.SongFeatures, error) {
+		var song models.SongFeatures
+		err := row.Scan(&song.ID, &song.GroupID, &song.ReleaseDate, &song.Text, &song.Tags)
+		return song, err
+	})
+	if err != nil {
+		log.Error("Rows scanning failed:", err)
+		return nil, nil, fmt.Errorf("mlib_repo: getSimilarityWork: features rows: %w", err)
+	}
+
+	log.Infof("Successfully fetched %d queued songs and the features of %d songs", len(jobs), len(songs))
+	return jobs, songs, nil
+}
+
+// SaveSimilarities replaces the similar songs of the queued song with similar and takes the
+// song off the queue, unless it was queued again meanwhile. Similarity is symmetric, so the song
+// is also added to, or updated in, the lists of the songs similar to it, each trimmed back to keep
+// songs. The song stays in the other lists it is in, which only lose an entry to a closer song.
+func (r *MLibRepository) SaveSimilarities(ctx context.Context, job models.SimilarityJob, similar []models.SimilarSong, keep int) error {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("SaveSimilarities", time.Now())
+	log.Debugf("SaveSimilarities called with song ID: %d, similar songs: %d", job.SongID, len(similar))
+
+	tx, err := r.BeginTx(ctx)
+	if err != nil {
+		log.Errorf("Failed to begin transaction: %v", err)
+		return fmt.Errorf("mlib_repo: saveSimilarities: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", similarityLock); err != nil {
+		log.Errorf("Failed to take the similarity lock: %v", err)
+		return fmt.Errorf("mlib_repo: saveSimilarities: lock: %w", err)
+	}
+
+	// A song deleted since it was compared takes its queue entry with it.
+	if err = lockSongTx(ctx, tx, job.SongID); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			log.Debugf("Song ID %d was deleted before its similarities were saved", job.SongID)
+			return nil
+		}
+		log.Errorf("Failed to lock song ID %d: %v", job.SongID, err)
+		return fmt.Errorf("mlib_repo: saveSimilarities: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, "DELETE FROM song_similarities WHERE song_id = $1", job.SongID)
+	if err != nil {
+		log.Errorf("Error deleting similarities of song ID %d: %v", job.SongID, err)
+		return fmt.Errorf("mlib_repo: saveSimilarities: delete: %w", err)
+	}
+
+	ids := make([]int, len(similar))
+	scores := make([]float64, len(similar))
+	sameGroups := make([]bool, len(similar))
+	sharedTags := make([]int, len(similar))
+	yearGaps := make([]*int, len(similar))
+	lyrics := make([]float64, len(similar))
+	for i, song := range similar {
+		ids[i], scores[i], sameGroups[i] = song.SongID, song.Score, song.SameGroup
+		sharedTags[i], yearGaps[i], lyrics[i] = song.SharedTags, song.YearGap, song.LyricsSimilarity
+	}
+
+	// Songs deleted since they were compared are left out by the join.
+	_, err = tx.Exec(ctx, `INSERT INTO song_similarities
+			(song_id, similar_id, score, same_group, shared_tags, year_gap, lyrics_similarity)
+		SELECT pair.song_id, pair.similar_id, u.score, u.same_group, u.shared_tags, u.year_gap, u.lyrics_similarity
+		FROM unnest($2::int[], $3::float8[], $4::boolean[], $5::int[], $6::int[], $7::float8[])
+			AS u(id, score, same_group, shared_tags, year_gap, lyrics_similarity)
+		JOIN songs AS s ON s.id = u.id
+		CROSS JOIN LATERAL (VALUES ($1::int, u.id), (u.id, $1::int)) AS pair(song_id, similar_id)
+		ON CONFLICT (song_id, similar_id) DO UPDATE SET score = EXCLUDED.score, same_group = EXCLUDED.same_group,
+			shared_tags = EXCLUDED.shared_tags, year_gap = EXCLUDED.year_gap,
+			lyrics_similarity = EXCLUDED.lyrics_similarity`,
+		job.SongID, ids, scores, sameGroups, sharedTags, yearGaps, lyrics)
+	if err != nil {
+		log.Errorf("Error inserting similarities of song ID %d: %v", job.SongID, err)
+		return fmt.Errorf("mlib_repo: saveSimilarities: insert: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM song_similarities AS ss
+		USING (SELECT song_id, similar_id,
+				row_number() OVER (PARTITION BY song_id ORDER BY score DESC, similar_id) AS rank
+			FROM song_similarities
+			WHERE song_id = ANY($1)) AS ranked
+		WHERE ss.song_id = ranked.song_id AND ss.similar_id = ranked.similar_id AND ranked.rank > $2`, ids, keep)
+	if err != nil {
+		log.Errorf("Error trimming similarities of songs similar to song ID %d: %v", job.SongID, err)
+		return fmt.Errorf("mlib_repo: saveSimilarities: trim: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, "DELETE FROM song_similarity_queue WHERE song_id = $1 AND queued_at = $2", job.SongID, job.QueuedAt)
+	if err != nil {
+		log.Errorf("Error dequeuing song ID %d: %v", job.SongID, err)
+		return fmt.Errorf("mlib_repo: saveSimilarities: dequeue: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Errorf("Transaction commit failed: %v", err)
+		return fmt.Errorf("mlib_repo: saveSimilarities: commit transaction: %w", err)
+	}
+	return nil
+}
+
+// queueSimilarityTx queues a changed song within tx for its similarities to be recomputed.
+func (r *MLibRepository) queueSimilarityTx(ctx context.Context, tx pgx.Tx, id int) error {
+	_, err := tx.Exec(ctx, `INSERT INTO song_similarity_queue (song_id) VALUES ($1)
+		ON CONFLICT (song_id) DO UPDATE SET queued_at = EXCLUDED.queued_at`, id)
+	if err != nil {
+		return fmt.Errorf("queue similarity: %w", err)
+	}
+	return nil
+}
+
+// queueSimilarSongsTx queues within tx the songs listing the song with the given id as similar, so
+// that their lists are refilled once it is deleted.
+func (r *MLibRepository) queueSimilarSongsTx(ctx context.Context, tx pgx.Tx, id int) error {
+	_, err := tx.Exec(ctx, `INSERT INTO song_similarity_queue (song_id)
+		SELECT song_id FROM song_similarities WHERE similar_id = $1
+		ON CONFLICT (song_id) DO UPDATE SET queued_at = EXCLUDED.queued_at`, id)
+	if err != nil {
+		return fmt.Errorf("queue similar songs: %w", err)
+	}
+	return nil
+}
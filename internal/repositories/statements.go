@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/sirupsen/logrus"
+)
+
+// The hot statements, run by the most frequent reads.
+const (
+	getTextSQL = "SELECT text FROM songs WHERE id=$1"
+
+	selectSongSQL = `SELECT s.id, g.group_name, s.song_name, s.release_date, s.text, s.link, s.explicit, s.profanity_count
+		FROM songs AS s
+		JOIN groups AS g ON s.group_id = g.id
+		WHERE s.id = $1`
+
+	getTranslationsSQL = `SELECT language, is_original
+		FROM song_lyrics
+		WHERE song_id = $1
+		ORDER BY is_original DESC, language`
+
+	getLyricsTextSQL = "SELECT text FROM song_lyrics WHERE song_id = $1 AND language = $2"
+
+	getSectionsSQL = `SELECT s.position, s.kind, l.text, l.start_ms, l.words
+		FROM song_sections AS s
+		JOIN song_lines AS l ON l.section_id = s.id
+		WHERE s.song_id = $1 AND s.language = $2
+		ORDER BY s.position, l.position`
+)
+
+var hotStatements = []string{getTextSQL, selectSongSQL, getTranslationsSQL, getLyricsTextSQL, getSectionsSQL}
+
+// PrepareStatements returns the pool's AfterConnect hook, which prepares the hot statements on a
+// new connection. Each statement is named after its SQL, so queries passing the same SQL run the
+// prepared statement. Nothing is prepared in the simple protocol mode, which is meant for poolers
+// that do not keep prepared statements, and the queries are then sent as text.
+//
+// Preparing is only a warm-up, so a statement the schema does not support yet, before migrations
+// ran or on a lagging replica, is skipped rather than failing the connection. Its queries are
+// then prepared on first use, or fail then.
+func PrepareStatements(log logrus.FieldLogger) func(context.Context, *pgx.Conn) error {
+	return func(ctx context.Context, conn *pgx.Conn) error {
+		if conn.Config().DefaultQueryExecMode == pgx.QueryExecModeSimpleProtocol {
+			return nil
+		}
+		skipped := 0
+		for _, sql := range hotStatements {
+			_, err := conn.Prepare(ctx, sql, sql)
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) {
+				log.Debugf("Skipping preparing statement %q: %v", sql, err)
+				skipped++
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("mlib_repo: prepare statements: %w", err)
+			}
+		}
+		if skipped > 0 {
+			log.Warnf("Skipped preparing %d of %d hot statements the schema does not support", skipped, len(hotStatements))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"music-library/internal/metrics"
+	"music-library/internal/models"
+	"music-library/internal/utils"
+	"time"
+)
+
+// GetLibraryStats aggregates the library: song counts per group, release year and decade,
+// the top longest songs by word count and the top groups by song count.
+func (r *MLibRepository) GetLibraryStats(ctx context.Context, top int) (models.LibraryStats, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("GetLibraryStats", time.Now())
+	log.Infof("Entering GetLibraryStats function, top: %d", top)
+
+	conn, err := r.acquireRead(ctx)
+	if err != nil {
+		log.Error("Failed to acquire DB connection:", err)
+		return models.LibraryStats{}, fmt.Errorf("mlib_repo: getLibraryStats: db acquire: %w", err)
+	}
+	defer conn.Release()
+
+	// A repeatable read transaction keeps the totals and breakdowns consistent with each other.
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		log.Errorf("Failed to begin transaction: %v", err)
+		return models.LibraryStats{}, fmt.Errorf("mlib_repo: getLibraryStats: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var stats models.LibraryStats
+	err = tx.QueryRow(ctx, "SELECT (SELECT COUNT(*) FROM songs), (SELECT COUNT(*) FROM groups)").Scan(&stats.Songs, &stats.Groups)
+	if err != nil {
+		log.Error("QueryRow failed:", err)
+		return models.LibraryStats{}, fmt.Errorf("mlib_repo: getLibraryStats: totals: %w", err)
+	}
+
+	stats.SongsPerGroup, err = collectStats(ctx, tx, `SELECT g.group_name, COUNT(s.id)
+		FROM groups AS g
+		LEFT JOIN songs AS s ON s.group_id = g.id
+		GROUP BY g.id
+		ORDER BY g.group_name`, scanGroupCount)
+	if err != nil {
+		log.Error("Songs per group query failed:", err)
+		return models.LibraryStats{}, fmt.Errorf("mlib_repo: getLibraryStats: songs per group: %w", err)
+	}
+
+	stats.TopGroups, err = collectStats(ctx, tx, `SELECT g.group_name, COUNT(s.id) AS songs
+		FROM groups AS g
+		JOIN songs AS s ON s.group_id = g.id
+		GROUP BY g.id
+		ORDER BY songs DESC, g.group_name
+		LIMIT $1`, scanGroupCount, top)
+	if err != nil {
+		log.Error("Top groups query failed:", err)
+		return models.LibraryStats{}, fmt.Errorf("mlib_repo: getLibraryStats: top groups: %w", err)
+	}
+
+	stats.SongsPerYear, err = collectStats(ctx, tx, `SELECT EXTRACT(YEAR FROM release_date)::INT AS period, COUNT(*)
+		FROM songs
+		GROUP BY period
+		ORDER BY period`, scanPeriodCount)
+	if err != nil {
+		log.Error("Songs per year query failed:", err)
+		return models.LibraryStats{}, fmt.Errorf("mlib_repo: getLibraryStats: songs per year: %w", err)
+	}
+
+	stats.SongsPerDecade, err = collectStats(ctx, tx, `SELECT (EXTRACT(YEAR FROM release_date)::INT / 10) * 10 AS period, COUNT(*)
+		FROM songs
+		GROUP BY period
+		ORDER BY period`, scanPeriodCount)
+	if err != nil {
+		log.Error("Songs per decade query failed:", err)
+		return models.LibraryStats{}, fmt.Errorf("mlib_repo: getLibraryStats: songs per decade: %w", err)
+	}
+
+	stats.LongestSongs, err = collectStats(ctx, tx, `SELECT s.id, g.group_name, s.song_name,
+			COALESCE(array_length(regexp_split_to_array(btrim(s.text), '\s+'), 1), 0) AS words
+		FROM songs AS s
+		JOIN groups AS g ON s.group_id = g.id
+		WHERE btrim(s.text) <> ''
+		ORDER BY words DESC, s.id
+		LIMIT $1`, func(row pgx.CollectableRow) (models.SongLength, error) {
+		var length models.SongLength
+		err := row.Scan(&length.SongID, &length.Group, &length.Song, &length.Words)
+		return length, err
+	}, top)
+	if err != nil {
+		log.Error("Longest songs query failed:", err)
+		return models.LibraryStats{}, fmt.Errorf("mlib_repo: getLibraryStats: longest songs: %w", err)
+	}
+
+	log.Infof("Successfully aggregated stats of %d songs", stats.Songs)
+	return stats, nil
+}
+
+// collectStats runs an aggregate query and scans every row, returning an empty slice for no rows.
+func collectStats[T any](ctx context.Context, tx pgx.Tx, query string, scan pgx.RowToFunc[T], args ...any) ([]T, error) {
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	result, err := pgx.CollectRows(rows, scan)
+	if err != nil {
+		return nil, fmt.Errorf("collect rows: %w", err)
+	}
+	if result == nil {
+		result = []T{}
+	}
+	return result, nil
+}
+
+func scanGroupCount(row pgx.CollectableRow) (models.GroupCount, error) {
+	var count models.GroupCount
+	err := row.Scan(&count.Group, &count.Songs)
+	return count, err
+}
+
+func scanPeriodCount(row pgx.CollectableRow) (models.PeriodCount, error) {
+	var count models.PeriodCount
+	err := row.Scan(&count.Period, &count.Songs)
+	return count, err
+}
@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"music-library/internal/metrics"
+	"music-library/internal/models"
+	"music-library/internal/utils"
+	"time"
+	"unicode/utf8"
+)
+
+// minInfixLength is the length from which a query is matched anywhere in names. The trigram
+// indexes cannot search shorter queries within names, so those only match the start of words.
+const minInfixLength = 3
+
+var (
+	suggestGroupsSQL      = suggestSQL("g.id, g.group_name, NULL::text", "groups AS g", "g.group_name", false)
+	suggestGroupsShortSQL = suggestSQL("g.id, g.group_name, NULL::text", "groups AS g", "g.group_name", true)
+	suggestSongsSQL       = suggestSQL("s.id, s.song_name, g.group_name", "songs AS s\n\t\tJOIN groups AS g ON g.id = s.group_id", "s.song_name", false)
+	suggestSongsShortSQL  = suggestSQL("s.id, s.song_name, g.group_name", "songs AS s\n\t\tJOIN groups AS g ON g.id = s.group_id", "s.song_name", true)
+)
+
+// suggestSQL builds a query suggesting names of the name column, matched against the query in
+// $1, its LIKE escaped form in $2, and limited to $3 rows. Case and diacritics are folded on both
+// sides by fold_text, which the trigram indexes are built on. Names starting with the query rank
+// first, then names with a word starting with it, each by trigram similarity.
+func suggestSQL(columns, from, name string, short bool) string {
+	folded := "fold_text(" + name + ")"
+	match := folded + " LIKE '%' || fold_text($2) || '%' OR " + folded + " % fold_text($1)"
+	if short {
+		match = folded + " LIKE fold_text($2) || '%' OR " + folded + " LIKE '% ' || fold_text($2) || '%'"
+	}
+	return "SELECT " + columns + ", similarity(" + folded + ", fold_text($1)) AS score" +
+		"\n\t\tFROM " + from +
+		"\n\t\tWHERE " + match +
+		"\n\t\tORDER BY CASE WHEN " + folded + " LIKE fold_text($2) || '%' THEN 0" +
+		" WHEN " + folded + " LIKE '% ' || fold_text($2) || '%' THEN 1 ELSE 2 END," +
+		" score DESC, length(" + name + "), " + name + ", 1" +
+		"\n\t\tLIMIT $3"
+}
+
+// Suggest returns up to top of the names of the given kind, models.SuggestGroups or
+// models.SuggestSongs, best matching query, the best match first.
+func (r *MLibRepository) Suggest(ctx context.Context, kind, query string, top int) ([]models.Suggestion, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("Suggest", time.Now())
+	log.Debugf("Entering Suggest function, type: %s, top: %d", kind, top)
+
+	short := utf8.RuneCountInString(query) < minInfixLength
+	var sql string
+	switch {
+	case kind == models.SuggestGroups && short:
+		sql = suggestGroupsShortSQL
+	case kind == models.SuggestGroups:
+		sql = suggestGroupsSQL
+	case short:
+		sql = suggestSongsShortSQL
+	default:
+		sql = suggestSongsSQL
+	}
+
+	conn, err := r.acquireRead(ctx)
+	if err != nil {
+		log.Error("Failed to acquire DB connection:", err)
+		return nil, fmt.Errorf("mlib_repo: suggest: db acquire: %w", err)
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, sql, query, likeEscaper.Replace(query), top)
+	if err != nil {
+		log.Error("Query execution failed:", err)
+		return nil, fmt.Errorf("mlib_repo: suggest: query: %w", err)
+	}
+	suggestions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Suggestion, error) {
+		var suggestion models.Suggestion
+		var score float32
+		err := row.Scan(&suggestion.ID, &suggestion.Name, &suggestion.Group, &score)
+		suggestion.Score = float64(score)
+		return suggestion, err
+	})
+	if err != nil {
+		log.Error("Rows scanning failed:", err)
+		return nil, fmt.Errorf("mlib_repo: suggest: rows: %w", err)
+	}
+
+	log.Debugf("Successfully found %d suggestions", len(suggestions))
+	return suggestions, nil
+}
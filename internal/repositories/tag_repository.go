@@ -0,0 +1,244 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"music-library/internal/metrics"
+	"music-library/internal/models"
+	"music-library/internal/utils"
+	"time"
+)
+
+// GetTags returns every tag in use with its number of songs, the most used first.
+func (r *MLibRepository) GetTags(ctx context.Context) ([]models.TagCount, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("GetTags", time.Now())
+	log.Info("Entering GetTags function")
+
+	conn, err := r.acquireRead(ctx)
+	if err != nil {
+		log.Error("Failed to acquire DB connection:", err)
+		return nil, fmt.Errorf("mlib_repo: getTags: db acquire: %w", err)
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, `SELECT t.name, COUNT(*)
+		FROM tags AS t
+		JOIN song_tags AS st ON st.tag_id = t.id
+		GROUP BY t.name
+		ORDER BY COUNT(*) DESC, t.name`)
+	if err != nil {
+		log.Error("Query execution failed:", err)
+		return nil, fmt.Errorf("mlib_repo: getTags: query: %w", err)
+	}
+	tags, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.TagCount, error) {
+		var tag models.TagCount
+		err := row.Scan(&tag.Name, &tag.Songs)
+		return tag, err
+	})
+	if err != nil {
+		log.Error("Rows scanning failed:", err)
+		return nil, fmt.Errorf("mlib_repo: getTags: rows: %w", err)
+	}
+
+	log.Infof("Successfully fetched %d tags", len(tags))
+	return tags, nil
+}
+
+// GetSongTags returns the tags of a song in alphabetical order.
+func (r *MLibRepository) GetSongTags(ctx context.Context, id int) ([]string, error) {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("GetSongTags", time.Now())
+	log.Infof("Entering GetSongTags function for song ID: %d", id)
+
+	conn, err := r.acquireRead(ctx)
+	if err != nil {
+		log.Error("Failed to acquire DB connection:", err)
+		return nil, fmt.Errorf("mlib_repo: getSongTags: db acquire: %w", err)
+	}
+	defer conn.Release()
+
+	// The songs row is always returned, with a NULL tag when the song has none.
+	rows, err := conn.Query(ctx, `SELECT t.name
+		FROM songs AS s
+		LEFT JOIN song_tags AS st ON st.song_id = s.id
+		LEFT JOIN tags AS t ON t.id = st.tag_id
+		WHERE s.id = $1
+		ORDER BY t.name`, id)
+	if err != nil {
+		log.Error("Query execution failed:", err)
+		return nil, fmt.Errorf("mlib_repo: getSongTags: query: %w", err)
+	}
+	names, err := pgx.CollectRows(rows, pgx.RowTo[*string])
+	if err != nil {
+		log.Error("Rows scanning failed:", err)
+		return nil, fmt.Errorf("mlib_repo: getSongTags: rows: %w", err)
+	}
+	if len(names) == 0 {
+		log.Warnf("Song ID %d does not exist", id)
+		return nil, fmt.Errorf("mlib_repo: getSongTags: song %d: %w", id, ErrNotFound)
+	}
+
+	tags := make([]string, 0, len(names))
+	for _, name := range names {
+		if name != nil {
+			tags = append(tags, *name)
+		}
+	}
+	log.Infof("Successfully fetched %d tags for song ID: %d", len(tags), id)
+	return tags, nil
+}
+
+// AddSongTag tags a song, creating the tag if needed. Tagging a song twice is not an error.
+func (r *MLibRepository) AddSongTag(ctx context.Context, id int, tag string) error {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("AddSongTag", time.Now())
+	log.Infof("AddSongTag called with song ID: %d, tag: %s", id, tag)
+
+	tx, err := r.BeginTx(ctx)
+	if err != nil {
+		log.Errorf("Failed to begin transaction: %v", err)
+		return fmt.Errorf("mlib_repo: addSongTag: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err = lockSongTx(ctx, tx, id); err != nil {
+		log.Warnf("Failed to lock song ID %d: %v", id, err)
+		return fmt.Errorf("mlib_repo: addSongTag: %w", err)
+	}
+
+	if err = r.addSongTagTx(ctx, tx, id, tag); err != nil {
+		log.Errorf("Error tagging song ID %d: %v", id, err)
+		return fmt.Errorf("mlib_repo: %w", err)
+	}
+
+	if err = r.queueSimilarityTx(ctx, tx, id); err != nil {
+		log.Errorf("Error queuing similarities of song ID %d: %v", id, err)
+		return fmt.Errorf("mlib_repo: addSongTag: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Errorf("Transaction commit failed: %v", err)
+		return fmt.Errorf("mlib_repo: addSongTag: commit transaction: %w", err)
+	}
+	log.Infof("Successfully tagged song ID %d with %s", id, tag)
+	return nil
+}
+
+// addSongTagTx tags a song within tx. The upsert locks the tag row, so a concurrent removal of
+// its last use cannot delete it before the song is linked.
+func (r *MLibRepository) addSongTagTx(ctx context.Context, tx pgx.Tx, id int, tag string) error {
+	var tagId int
+	err := tx.QueryRow(ctx, `INSERT INTO tags (name) VALUES ($1)
+		ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+		RETURNING id`, tag).Scan(&tagId)
+	if err != nil {
+		return fmt.Errorf("addSongTag: upsert tag: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, "INSERT INTO song_tags (song_id, tag_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", id, tagId)
+	if err != nil {
+		return fmt.Errorf("addSongTag: insert song tag: %w", err)
+	}
+	return nil
+}
+
+// RemoveSongTag removes a tag from a song and deletes the tag once no song carries it.
+func (r *MLibRepository) RemoveSongTag(ctx context.Context, id int, tag string) error {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("RemoveSongTag", time.Now())
+	log.Infof("RemoveSongTag called with song ID: %d, tag: %s", id, tag)
+
+	tx, err := r.BeginTx(ctx)
+	if err != nil {
+		log.Errorf("Failed to begin transaction: %v", err)
+		return fmt.Errorf("mlib_repo: removeSongTag: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var tagId int
+	err = tx.QueryRow(ctx, `DELETE FROM song_tags AS st
+		USING tags AS t
+		WHERE st.tag_id = t.id AND st.song_id = $1 AND t.name = $2
+		RETURNING st.tag_id`, id, tag).Scan(&tagId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Warnf("Song ID %d is not tagged with %s", id, tag)
+		return fmt.Errorf("mlib_repo: removeSongTag: tag %q of song %d: %w", tag, id, ErrNotFound)
+	}
+	if err != nil {
+		log.Errorf("Error removing tag %s from song ID %d: %v", tag, id, err)
+		return fmt.Errorf("mlib_repo: removeSongTag: delete song tag: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, "DELETE FROM tags WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM song_tags WHERE tag_id = $1)", tagId)
+	if err != nil {
+		log.Errorf("Error deleting unused tag %s: %v", tag, err)
+		return fmt.Errorf("mlib_repo: removeSongTag: delete unused tag: %w", err)
+	}
+
+	if err = r.queueSimilarityTx(ctx, tx, id); err != nil {
+		log.Errorf("Error queuing similarities of song ID %d: %v", id, err)
+		return fmt.Errorf("mlib_repo: removeSongTag: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Errorf("Transaction commit failed: %v", err)
+		return fmt.Errorf("mlib_repo: removeSongTag: commit transaction: %w", err)
+	}
+	log.Infof("Successfully removed tag %s from song ID %d", tag, id)
+	return nil
+}
+
+// DeleteTag deletes a tag and removes it from every song.
+func (r *MLibRepository) DeleteTag(ctx context.Context, tag string) error {
+	log := utils.LoggerFromContext(ctx, r.log)
+	defer metrics.ObserveRepoQuery("DeleteTag", time.Now())
+	log.Infof("DeleteTag called with tag: %s", tag)
+
+	tx, err := r.BeginTx(ctx)
+	if err != nil {
+		log.Errorf("Failed to begin transaction: %v", err)
+		return fmt.Errorf("mlib_repo: deleteTag: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// The songs losing the tag are queued for their similarities to be recomputed.
+	_, err = tx.Exec(ctx, `INSERT INTO song_similarity_queue (song_id)
+		SELECT st.song_id FROM song_tags AS st JOIN tags AS t ON t.id = st.tag_id WHERE t.name = $1
+		ON CONFLICT (song_id) DO UPDATE SET queued_at = EXCLUDED.queued_at`, tag)
+	if err != nil {
+		log.Errorf("Error queuing similarities of songs tagged %s: %v", tag, err)
+		return fmt.Errorf("mlib_repo: deleteTag: queue similarities: %w", err)
+	}
+
+	result, err := tx.Exec(ctx, "DELETE FROM tags WHERE name = $1", tag)
+	if err != nil {
+		log.Errorf("Error deleting tag %s: %v", tag, err)
+		return fmt.Errorf("mlib_repo: deleteTag: delete: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("mlib_repo: deleteTag: tag %q: %w", tag, ErrNotFound)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Errorf("Transaction commit failed: %v", err)
+		return fmt.Errorf("mlib_repo: deleteTag: commit transaction: %w", err)
+	}
+
+	log.Infof("Successfully deleted tag %s", tag)
+	return nil
+}
+
+// deleteSongTagsTx deletes the tags carried only by the song about to be deleted. Its other
+// song_tags rows go with the song.
+func (r *MLibRepository) deleteSongTagsTx(ctx context.Context, tx pgx.Tx, id int) error {
+	_, err := tx.Exec(ctx, `DELETE FROM tags AS t
+		WHERE t.id IN (SELECT tag_id FROM song_tags WHERE song_id = $1)
+		AND NOT EXISTS (SELECT 1 FROM song_tags AS st WHERE st.tag_id = t.id AND st.song_id <> $1)`, id)
+	if err != nil {
+		return fmt.Errorf("delete unused tags: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+// Package seed provides the named data sets loaded by "music-library seed". Fixture sets are
+// the JSON or YAML files embedded from fixtures/, the synthetic set is generated for load tests.
+package seed
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io/fs"
+	"music-library/internal/models"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+//go:embed fixtures
+var fixtures embed.FS
+
+// Synthetic names the generated seed set.
+const Synthetic = "synthetic"
+
+// fixture is the file format of a fixture seed set.
+type fixture struct {
+	Songs []fixtureSong `json:"songs" yaml:"songs"`
+}
+
+type fixtureSong struct {
+	Group       string `json:"group" yaml:"group"`
+	Song        string `json:"song" yaml:"song"`
+	ReleaseDate string `json:"releaseDate" yaml:"releaseDate"`
+	Text        string `json:"text" yaml:"text"`
+	Link        string `json:"link" yaml:"link"`
+	Genre       string `json:"genre" yaml:"genre"`
+}
+
+// Names returns the available seed sets in alphabetical order.
+func Names() ([]string, error) {
+	entries, err := fs.ReadDir(fixtures, "fixtures")
+	if err != nil {
+		return nil, fmt.Errorf("seed: read fixtures: %w", err)
+	}
+	names := []string{Synthetic}
+	for _, entry := range entries {
+		if name, ok := fixtureName(entry.Name()); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Load returns the songs of the named seed set. count is the number of songs generated by the
+// synthetic set and is ignored by fixture sets.
+func Load(name string, count int) ([]models.Song, error) {
+	if name == Synthetic {
+		if count < 1 {
+			return nil, fmt.Errorf("seed: %s: count must be positive, got %d", name, count)
+		}
+		return Generate(count), nil
+	}
+
+	entries, err := fs.ReadDir(fixtures, "fixtures")
+	if err != nil {
+		return nil, fmt.Errorf("seed: read fixtures: %w", err)
+	}
+	for _, entry := range entries {
+		if n, ok := fixtureName(entry.Name()); ok && n == name {
+			return loadFixture(path.Join("fixtures", entry.Name()))
+		}
+	}
+	return nil, fmt.Errorf("seed: unknown seed set %q", name)
+}
+
+// fixtureName returns the seed set name of a fixture file, its name without the extension.
+func fixtureName(file string) (string, bool) {
+	switch ext := path.Ext(file); ext {
+	case ".json", ".yaml", ".yml":
+		return strings.TrimSuffix(file, ext), true
+	default:
+		return "", false
+	}
+}
+
+func loadFixture(file string) ([]models.Song, error) {
+	data, err := fixtures.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("seed: read %s: %w", file, err)
+	}
+
+	var f fixture
+	if path.Ext(file) == ".json" {
+		err = json.Unmarshal(data, &f)
+	} else {
+		err = yaml.Unmarshal(data, &f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("seed: parse %s: %w", file, err)
+	}
+
+	songs := make([]models.Song, 0, len(f.Songs))
+	for i, s := range f.Songs {
+		if s.Group == "" || s.Song == "" {
+			return nil, fmt.Errorf("seed: %s: song %d: group and song are required", file, i+1)
+		}
+		releaseDate, err := time.Parse("2006-01-02", s.ReleaseDate)
+		if err != nil {
+			return nil, fmt.Errorf("seed: %s: song %q: invalid release date: %w", file, s.Song, err)
+		}
+		song := models.Song{
+			Group:       &s.Group,
+			Song:        &s.Song,
+			ReleaseDate: &releaseDate,
+			Text:        &s.Text,
+			Link:        &s.Link,
+		}
+		if s.Genre != "" {
+			song.Genre = &s.Genre
+		}
+		songs = append(songs, song)
+	}
+	return songs, nil
+}
@@ -0,0 +1,67 @@
+package seed
+
+import (
+	"fmt"
+	"math/rand"
+	"music-library/internal/models"
+	"strings"
+	"time"
+)
+
+// songsPerGroup is the number of synthetic songs attributed to each synthetic group.
+const songsPerGroup = 10
+
+var syntheticWords = strings.Fields(`
+	love heart night light fire rain road home dream time world sky sun moon star river city
+	summer winter morning shadow window door train highway ocean song voice hand eye soul
+	run fall rise hold break burn shine fade wait call dance sing cry walk turn stay
+	cold bright lonely golden silent wild broken easy slow little long last gone true`)
+
+// Generate returns count synthetic songs for load testing. Every song only depends on its
+// number, so loading a larger count later adds the missing songs and keeps the others.
+func Generate(count int) []models.Song {
+	first := time.Date(1960, time.January, 1, 0, 0, 0, 0, time.UTC)
+	days := int(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC).Sub(first).Hours() / 24)
+
+	songs := make([]models.Song, 0, count)
+	for i := 1; i <= count; i++ {
+		rnd := rand.New(rand.NewSource(int64(i)))
+		group := fmt.Sprintf("Synthetic Group %04d", (i-1)/songsPerGroup+1)
+		song := fmt.Sprintf("Synthetic Song %06d", i)
+		releaseDate := first.AddDate(0, 0, rnd.Intn(days))
+		text := syntheticLyrics(rnd)
+		link := fmt.Sprintf("https://example.com/synthetic/%06d", i)
+		songs = append(songs, models.Song{
+			Group:       &group,
+			Song:        &song,
+			ReleaseDate: &releaseDate,
+			Text:        &text,
+			Link:        &link,
+		})
+	}
+	return songs
+}
+
+// syntheticLyrics returns two to four verses with a chorus repeated after each of them.
+func syntheticLyrics(rnd *rand.Rand) string {
+	chorus := syntheticStanza(rnd, 2+rnd.Intn(3))
+	verses := 2 + rnd.Intn(3)
+
+	stanzas := make([]string, 0, verses*2)
+	for v := 0; v < verses; v++ {
+		stanzas = append(stanzas, syntheticStanza(rnd, 4), chorus)
+	}
+	return strings.Join(stanzas, "\n\n")
+}
+
+func syntheticStanza(rnd *rand.Rand, lines int) string {
+	stanza := make([]string, lines)
+	for l := range stanza {
+		words := make([]string, 4+rnd.Intn(5))
+		for w := range words {
+			words[w] = syntheticWords[rnd.Intn(len(syntheticWords))]
+		}
+		stanza[l] = strings.ToUpper(words[0][:1]) + strings.Join(words, " ")[1:]
+	}
+	return strings.Join(stanza, "\n")
+}
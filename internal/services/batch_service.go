@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"music-library/internal/metrics"
+	"music-library/internal/models"
+	"music-library/internal/tracing"
+	"music-library/internal/utils"
+)
+
+// batchStep is a validated batch operation ready to run against the database.
+type batchStep struct {
+	op     string
+	id     int
+	song   models.Song
+	lyrics models.Lyrics
+	update models.SongUpdate
+	edit   *models.Lyrics
+}
+
+// Batch runs add, edit and delete operations in a single transaction. In atomic mode the first
+// failure rolls back every operation and is returned along with the results. In best-effort mode
+// each operation runs in its own savepoint, so failed operations are rolled back alone and the
+// rest is committed. New songs are completed by the external API before the transaction starts.
+func (s *MLibService) Batch(ctx context.Context, req models.BatchRequest) (models.BatchResponse, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.Batch func")
+	ctx, span := tracer.Start(ctx, "MLibService.Batch")
+	defer span.End()
+
+	mode := req.Mode
+	if mode == "" {
+		mode = models.BatchAtomic
+	}
+	span.SetAttributes(attribute.String("batch.mode", mode), attribute.Int("batch.operations", len(req.Operations)))
+
+	resp := models.BatchResponse{Mode: mode, Results: make([]models.BatchResult, len(req.Operations))}
+	steps := make([]*batchStep, len(req.Operations))
+	for i, op := range req.Operations {
+		resp.Results[i] = models.BatchResult{Index: i, Op: op.Op, ID: op.ID, Status: models.BatchStatusSkipped}
+
+		step, err := s.prepareBatchStep(ctx, op)
+		if err != nil {
+			resp.Results[i].Status = models.BatchStatusFailed
+			resp.Results[i].Error = err.Error()
+			if mode == models.BatchAtomic {
+				return failBatch(span, resp, fmt.Errorf("mlib service: batch: operation %d: %w", i, err))
+			}
+			continue
+		}
+		steps[i] = step
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return failBatch(span, resp, fmt.Errorf("mlib service: batch: %w", err))
+	}
+	defer tx.Rollback(ctx)
+
+	for i, step := range steps {
+		if step == nil {
+			continue
+		}
+		if mode == models.BatchAtomic {
+			err = s.runBatchStep(ctx, tx, step)
+		} else {
+			err = s.runBatchStepInSavepoint(ctx, tx, step)
+		}
+		if err != nil {
+			log.Warnf("Batch: Operation %d (%s) failed: %v", i, step.op, err)
+			resp.Results[i].Status = models.BatchStatusFailed
+			resp.Results[i].Error = err.Error()
+			if mode == models.BatchAtomic {
+				return failBatch(span, resp, fmt.Errorf("mlib service: batch: operation %d: %w", i, err))
+			}
+			continue
+		}
+		resp.Results[i].Status = models.BatchStatusOK
+		resp.Results[i].ID = &step.id
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return failBatch(span, resp, fmt.Errorf("mlib service: batch: commit tx: %w", err))
+	}
+	resp.Committed = true
+
+	changed := false
+	for _, result := range resp.Results {
+		if result.Status != models.BatchStatusOK {
+			continue
+		}
+		switch result.Op {
+		case models.BatchOpAdd:
+			metrics.SongsAdded.Inc()
+			changed = true
+		case models.BatchOpEdit:
+			metrics.SongsEdited.Inc()
+			changed = true
+		case models.BatchOpDelete:
+			metrics.SongsDeleted.Inc()
+			changed = true
+		}
+	}
+	if changed {
+		s.requestSimilarityRefresh()
+	}
+
+	log.Infof("Batch: Committed %d operations in %s mode", len(req.Operations), mode)
+	return resp, nil
+}
+
+// failBatch marks every successful operation of an uncommitted batch as rolled back.
+func failBatch(span trace.Span, resp models.BatchResponse, err error) (models.BatchResponse, error) {
+	tracing.RecordError(span, err)
+	for i := range resp.Results {
+		if resp.Results[i].Status == models.BatchStatusOK {
+			resp.Results[i].Status = models.BatchStatusRolledBack
+		}
+	}
+	resp.Error = err.Error()
+	return resp, err
+}
+
+func (s *MLibService) prepareBatchStep(ctx context.Context, op models.BatchOperation) (*batchStep, error) {
+	step := &batchStep{op: op.Op}
+	if op.Op != models.BatchOpAdd {
+		if op.ID == nil {
+			return nil, fmt.Errorf("%w: id is required for %s", ErrInvalidInput, op.Op)
+		}
+		step.id = *op.ID
+	}
+
+	switch op.Op {
+	case models.BatchOpAdd:
+		if op.Song == nil || op.Song.Group == nil || op.Song.Song == nil {
+			return nil, fmt.Errorf("%w: song with group and song is required for add", ErrInvalidInput)
+		}
+		song, lyrics, err := s.prepareSong(ctx, models.Song{Group: op.Song.Group, Song: op.Song.Song})
+		if err != nil {
+			return nil, err
+		}
+		step.song, step.lyrics = song, lyrics
+	case models.BatchOpEdit:
+		if op.Edit == nil {
+			return nil, fmt.Errorf("%w: edit is required for edit", ErrInvalidInput)
+		}
+		update, edit, err := s.songUpdate(ctx, step.id, *op.Edit)
+		if err != nil {
+			return nil, err
+		}
+		step.update, step.edit = update, edit
+		if step.update.Empty() {
+			return nil, fmt.Errorf("%w: edit has no changes", ErrInvalidInput)
+		}
+	case models.BatchOpDelete:
+	default:
+		return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidInput, op.Op)
+	}
+	return step, nil
+}
+
+func (s *MLibService) runBatchStep(ctx context.Context, tx pgx.Tx, step *batchStep) error {
+	switch step.op {
+	case models.BatchOpAdd:
+		song, err := s.repo.AddSongTx(ctx, tx, step.song, step.lyrics)
+		if err != nil {
+			return err
+		}
+		step.id = *song.ID
+		return nil
+	case models.BatchOpEdit:
+		_, err := s.repo.EditSongTx(ctx, tx, step.id, step.update, step.edit)
+		return err
+	default:
+		return s.repo.DeleteSongTx(ctx, tx, step.id)
+	}
+}
+
+// runBatchStepInSavepoint runs a step so that its failure only undoes its own changes.
+func (s *MLibService) runBatchStepInSavepoint(ctx context.Context, tx pgx.Tx, step *batchStep) error {
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("savepoint: %w", err)
+	}
+	if err = s.runBatchStep(ctx, savepoint, step); err != nil {
+		if rollbackErr := savepoint.Rollback(ctx); rollbackErr != nil {
+			return errors.Join(err, fmt.Errorf("rollback to savepoint: %w", rollbackErr))
+		}
+		return err
+	}
+	if err = savepoint.Commit(ctx); err != nil {
+		return fmt.Errorf("release savepoint: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"music-library/internal/models"
+	"music-library/internal/tracing"
+	"music-library/internal/utils"
+)
+
+// AddFavorite marks a song as a favorite of the user making the request.
+func (s *MLibService) AddFavorite(ctx context.Context, id int) error {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.AddFavorite func")
+	ctx, span := tracer.Start(ctx, "MLibService.AddFavorite")
+	defer span.End()
+	span.SetAttributes(attribute.Int("song.id", id))
+
+	user, err := currentUser(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("mlib service: addFavorite: %w", err)
+	}
+
+	if err = s.repo.AddFavorite(ctx, user, id); err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("mlib service: addFavorite: repo: %w", err)
+	}
+
+	log.Infof("AddFavorite: Favorited song ID %d", id)
+	return nil
+}
+
+// RemoveFavorite removes a song from the favorites of the user making the request.
+func (s *MLibService) RemoveFavorite(ctx context.Context, id int) error {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.RemoveFavorite func")
+	ctx, span := tracer.Start(ctx, "MLibService.RemoveFavorite")
+	defer span.End()
+	span.SetAttributes(attribute.Int("song.id", id))
+
+	user, err := currentUser(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("mlib service: removeFavorite: %w", err)
+	}
+
+	if err = s.repo.RemoveFavorite(ctx, user, id); err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("mlib service: removeFavorite: repo: %w", err)
+	}
+
+	log.Infof("RemoveFavorite: Unfavorited song ID %d", id)
+	return nil
+}
+
+// GetFavorites returns a page of the favorite songs of the user making the request.
+func (s *MLibService) GetFavorites(ctx context.Context, page, limit int) ([]models.Song, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.GetFavorites func")
+	ctx, span := tracer.Start(ctx, "MLibService.GetFavorites")
+	defer span.End()
+
+	user, err := currentUser(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: getFavorites: %w", err)
+	}
+	if page < 1 {
+		page = 1
+		log.Info("Page is less than 1, defaulting to 1")
+	}
+	if limit < 1 {
+		limit = 10
+		log.Info("Limit is less than 1, defaulting to 10")
+	}
+
+	songs, err := s.repo.GetFavorites(ctx, user, page, limit)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: getFavorites: repo: %w", err)
+	}
+	if songs == nil {
+		songs = []models.Song{}
+	}
+	return songs, nil
+}
+
+// RecordPlay records a play of a song, in the listening history of the user making the request
+// when known.
+func (s *MLibService) RecordPlay(ctx context.Context, id int) error {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.RecordPlay func")
+	ctx, span := tracer.Start(ctx, "MLibService.RecordPlay")
+	defer span.End()
+	span.SetAttributes(attribute.Int("song.id", id))
+
+	if err := s.repo.RecordPlay(ctx, utils.UserFromContext(ctx), id); err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("mlib service: recordPlay: repo: %w", err)
+	}
+
+	log.Infof("RecordPlay: Recorded play of song ID %d", id)
+	return nil
+}
+
+// GetPlays returns a page of the listening history of the user making the request.
+func (s *MLibService) GetPlays(ctx context.Context, page, limit int) ([]models.Play, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.GetPlays func")
+	ctx, span := tracer.Start(ctx, "MLibService.GetPlays")
+	defer span.End()
+
+	user, err := currentUser(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: getPlays: %w", err)
+	}
+	if page < 1 {
+		page = 1
+		log.Info("Page is less than 1, defaulting to 1")
+	}
+	if limit < 1 {
+		limit = 10
+		log.Info("Limit is less than 1, defaulting to 10")
+	}
+
+	plays, err := s.repo.GetPlays(ctx, user, page, limit)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: getPlays: repo: %w", err)
+	}
+	if plays == nil {
+		plays = []models.Play{}
+	}
+	return plays, nil
+}
+
+// GetPopularSongs returns up to top of the most played songs, of the whole library or of the
+// group with the given name.
+func (s *MLibService) GetPopularSongs(ctx context.Context, group *string, top int) ([]models.PopularSong, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.GetPopularSongs func")
+	ctx, span := tracer.Start(ctx, "MLibService.GetPopularSongs")
+	defer span.End()
+
+	if top < 1 {
+		top = 10
+		log.Info("Top is less than 1, defaulting to 10")
+	}
+	if group != nil {
+		span.SetAttributes(attribute.String("group", *group))
+	}
+
+	songs, err := s.repo.GetPopularSongs(ctx, group, top)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: getPopularSongs: repo: %w", err)
+	}
+	if songs == nil {
+		songs = []models.PopularSong{}
+	}
+	return songs, nil
+}
+
+// GetTrendingSongs returns up to top of the songs played the most in the last days days.
+func (s *MLibService) GetTrendingSongs(ctx context.Context, days, top int) ([]models.PopularSong, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.GetTrendingSongs func")
+	ctx, span := tracer.Start(ctx, "MLibService.GetTrendingSongs")
+	defer span.End()
+	span.SetAttributes(attribute.Int("days", days))
+
+	if days < 1 {
+		days = 7
+		log.Info("Days is less than 1, defaulting to 7")
+	}
+	if top < 1 {
+		top = 10
+		log.Info("Top is less than 1, defaulting to 10")
+	}
+
+	songs, err := s.repo.GetTrendingSongs(ctx, days, top)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: getTrendingSongs: repo: %w", err)
+	}
+	if songs == nil {
+		songs = []models.PopularSong{}
+	}
+	return songs, nil
+}
@@ -0,0 +1,21 @@
+package services
+
+import (
+	"errors"
+	"music-library/internal/repositories"
+)
+
+var (
+	// ErrNotFound is returned when the requested resource does not exist.
+	ErrNotFound = repositories.ErrNotFound
+	// ErrConflict is returned when a change conflicts with the current state of a resource.
+	ErrConflict = repositories.ErrConflict
+	// ErrForbidden is returned when a resource may only be changed by the user owning it.
+	ErrForbidden = repositories.ErrForbidden
+	// ErrUnauthorized is returned when a request needs to know its user and does not.
+	ErrUnauthorized = errors.New("unknown user")
+	// ErrInvalidInput is returned when a request is well-formed but its values are not acceptable.
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrIdempotencyKeyReused is returned when an idempotency key is sent again with a different request.
+	ErrIdempotencyKeyReused = errors.New("idempotency key reused with a different request")
+)
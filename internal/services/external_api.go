@@ -1,60 +1,133 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
 	"music-library/config"
+	"music-library/internal/metrics"
 	"music-library/internal/models"
+	"music-library/internal/tracing"
+	"music-library/internal/utils"
 	"net/http"
 	"strings"
+	"time"
 )
 
+const (
+	breakerThreshold   = 5
+	breakerOpenTimeout = 30 * time.Second
+)
+
+// ErrExternalBadRequest is returned when the external API rejects the requested song.
+// It does not count as a failure for the circuit breaker.
+var ErrExternalBadRequest = errors.New("BadRequest")
+
 type ExternalAPIClient struct {
-	cfg *config.Config
-	log *logrus.Logger
+	cfg     *config.Config
+	log     *logrus.Logger
+	client  *http.Client
+	breaker *circuitBreaker
 }
 
 func NewExternalAPIClient(cfg *config.Config, log *logrus.Logger) *ExternalAPIClient {
-	return &ExternalAPIClient{cfg: cfg, log: log}
+	return &ExternalAPIClient{
+		cfg:     cfg,
+		log:     log,
+		client:  &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)},
+		breaker: newCircuitBreaker(breakerThreshold, breakerOpenTimeout),
+	}
 }
 
-func (e *ExternalAPIClient) GetSongDetails(song *models.Song) error {
-	e.log.Infof("GetSongDetails called for Group: %s, Song: %s", *song.Group, *song.Song)
+func (e *ExternalAPIClient) GetSongDetails(ctx context.Context, song *models.Song) error {
+	log := utils.LoggerFromContext(ctx, e.log)
+	log.Infof("GetSongDetails called for Group: %s, Song: %s", *song.Group, *song.Song)
+	ctx, span := tracer.Start(ctx, "ExternalAPIClient.GetSongDetails")
+	defer span.End()
+	span.SetAttributes(attribute.String("song.group", *song.Group), attribute.String("song.name", *song.Song))
+
+	if err := e.breaker.allow(); err != nil {
+		log.Warn("External API circuit breaker is open, skipping request")
+		metrics.ExternalAPIRequests.WithLabelValues("breaker_open").Inc()
+		err = fmt.Errorf("external_api: getSongDetails: %w", err)
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	err := e.getSongDetails(ctx, song)
+	if errors.Is(err, ErrExternalBadRequest) {
+		e.breaker.success()
+		metrics.ExternalAPIRequests.WithLabelValues("bad_request").Inc()
+		tracing.RecordError(span, err)
+		return err
+	}
+	if err != nil && ctx.Err() != nil {
+		e.breaker.abort()
+		metrics.ExternalAPIRequests.WithLabelValues("canceled").Inc()
+		tracing.RecordError(span, err)
+		return err
+	}
+	if err != nil {
+		e.breaker.failure()
+		tracing.RecordError(span, err)
+		metrics.ExternalAPIRequests.WithLabelValues("error").Inc()
+		return err
+	}
+	e.breaker.success()
+	metrics.ExternalAPIRequests.WithLabelValues("success").Inc()
+	return nil
+}
+
+func (e *ExternalAPIClient) getSongDetails(ctx context.Context, song *models.Song) error {
+	log := utils.LoggerFromContext(ctx, e.log)
+	start := time.Now()
+	defer func() {
+		metrics.ExternalAPIDuration.Observe(time.Since(start).Seconds())
+	}()
 
 	groupWithoutSpaces := strings.ReplaceAll(*song.Group, " ", "%20")
 	songWithoutSpaces := strings.ReplaceAll(*song.Song, " ", "%20")
 
 	url := fmt.Sprintf("%s/info?group=%s&song=%s", e.cfg.ExternalAPI, groupWithoutSpaces, songWithoutSpaces)
-	e.log.Debugf("Making GET request to URL: %s", url)
+	log.Debugf("Making GET request to URL: %s", url)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		log.Errorf("Failed to build HTTP request: %v", err)
+		return fmt.Errorf("external_api: getSongDetails: new request: %w", err)
+	}
 
-	resp, err := http.Get(url)
+	resp, err := e.client.Do(req)
 	if err != nil {
-		e.log.Errorf("HTTP GET request failed: %v", err)
+		log.Errorf("HTTP GET request failed: %v", err)
 		return fmt.Errorf("external_api: getSongDetails: http get: %w", err)
 	}
 	defer func() {
 		if cerr := resp.Body.Close(); cerr != nil {
-			e.log.Warnf("Failed to close response body: %v", cerr)
+			log.Warnf("Failed to close response body: %v", cerr)
 		}
 	}()
-	e.log.Infof("Received response with status code: %d", resp.StatusCode)
+	log.Infof("Received response with status code: %d", resp.StatusCode)
 
 	if resp.StatusCode != http.StatusOK {
 		if resp.StatusCode == http.StatusBadRequest {
-			e.log.Warn("External API returned BadRequest (400)")
-			return fmt.Errorf("external_api: getSongDetails: BadRequest")
+			log.Warn("External API returned BadRequest (400)")
+			return fmt.Errorf("external_api: getSongDetails: %w", ErrExternalBadRequest)
 		} else {
-			e.log.Error("External API returned an unexpected status code")
+			log.Error("External API returned an unexpected status code")
 			return fmt.Errorf("external_api: getSongDetails: InternalServerError")
 		}
 	}
 
 	if err = json.NewDecoder(resp.Body).Decode(&song); err != nil {
-		e.log.Errorf("Failed to decode JSON response: %v", err)
+		log.Errorf("Failed to decode JSON response: %v", err)
 		return fmt.Errorf("external_api: getSongDetails: json decode: %w", err)
 	}
-	e.log.Infof("Successfully decoded song details: %+v", song)
+	log.WithFields(utils.SongFields(*song)).Info("Successfully decoded song details")
 
 	return nil
 }
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"music-library/internal/models"
+	"music-library/internal/tracing"
+	"music-library/internal/utils"
+	"time"
+)
+
+// idempotentSaveTimeout bounds storing a response, which goes on after the request is canceled.
+const idempotentSaveTimeout = 5 * time.Second
+
+// ClaimIdempotencyKey claims an idempotency key for a request until lease has passed. It returns
+// the stored response to replay when the key was already answered within its ttl, and nil when the
+// request now holds the key and should be handled, then finished with FinishIdempotent. Reusing a
+// key with a different request hash fails with ErrIdempotencyKeyReused, and a key claimed by a
+// request still being handled with ErrConflict.
+func (s *MLibService) ClaimIdempotencyKey(ctx context.Context, key, requestHash string, lease time.Duration) (*models.IdempotentResponse, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.ClaimIdempotencyKey func")
+	ctx, span := tracer.Start(ctx, "MLibService.ClaimIdempotencyKey")
+	defer span.End()
+
+	stored, err := s.repo.ClaimIdempotencyKey(ctx, key, requestHash, lease)
+	switch {
+	case err != nil:
+		err = fmt.Errorf("repo: %w", err)
+	case stored == nil:
+	case stored.RequestHash != requestHash:
+		err = fmt.Errorf("key %q: %w", key, ErrIdempotencyKeyReused)
+	case stored.Pending:
+		err = fmt.Errorf("key %q: a request with this key is still being handled: %w", key, ErrConflict)
+	}
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: claimIdempotencyKey: %w", err)
+	}
+
+	log.Debugf("MLibService.ClaimIdempotencyKey success, replayed: %t", stored != nil)
+	return stored, nil
+}
+
+// FinishIdempotent stores the response to a request holding an idempotency key, to be replayed
+// for ttl, or releases the key when response is nil, for responses that should not be replayed
+// such as server errors. It runs even when the request was canceled meanwhile, since what the
+// request changed is committed and a retry must not repeat it.
+func (s *MLibService) FinishIdempotent(ctx context.Context, key, requestHash string, response *models.IdempotentResponse, ttl time.Duration) error {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.FinishIdempotent func")
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), idempotentSaveTimeout)
+	defer cancel()
+	ctx, span := tracer.Start(ctx, "MLibService.FinishIdempotent")
+	defer span.End()
+
+	var err error
+	if response == nil {
+		err = s.repo.ReleaseIdempotencyKey(ctx, key, requestHash)
+	} else {
+		err = s.repo.SaveIdempotentResponse(ctx, key, requestHash, *response, ttl)
+	}
+	if err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("mlib service: finishIdempotent: repo: %w", err)
+	}
+	return nil
+}
+
+// PurgeIdempotencyKeys deletes the expired idempotency keys every interval until ctx is done.
+func (s *MLibService) PurgeIdempotencyKeys(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.repo.PurgeIdempotencyKeys(ctx)
+			if err != nil {
+				s.log.Errorf("Failed to purge expired idempotency keys: %v", err)
+				continue
+			}
+			if purged > 0 {
+				s.log.Infof("Purged %d expired idempotency keys", purged)
+			}
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/text/language"
+	"music-library/internal/metrics"
+	"music-library/internal/models"
+	"music-library/internal/tracing"
+	"music-library/internal/utils"
+)
+
+// GetLyrics returns the lyrics of a song in the best match for the preferred languages,
+// falling back to the original lyrics.
+func (s *MLibService) GetLyrics(ctx context.Context, id int, prefs []language.Tag) (models.Lyrics, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.GetLyrics func")
+	ctx, span := tracer.Start(ctx, "MLibService.GetLyrics")
+	defer span.End()
+	span.SetAttributes(attribute.Int("song.id", id))
+
+	translation, err := s.pickTranslation(ctx, id, prefs)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return models.Lyrics{}, fmt.Errorf("mlib service: getLyrics: %w", err)
+	}
+
+	lyrics, err := s.getLyrics(ctx, id, translation.Language)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return models.Lyrics{}, fmt.Errorf("mlib service: getLyrics: %w", err)
+	}
+
+	log.Debug("MLibService.GetLyrics success")
+	return lyrics, nil
+}
+
+// GetAlignedVerses returns a page of verses of the lyrics in the preferred language side by side
+// with the verses of the lyrics in the with language, matched by index.
+func (s *MLibService) GetAlignedVerses(ctx context.Context, id int, prefs []language.Tag, with string, page, limit int) ([]models.AlignedVerse, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.GetAlignedVerses func")
+	ctx, span := tracer.Start(ctx, "MLibService.GetAlignedVerses")
+	defer span.End()
+	span.SetAttributes(attribute.Int("song.id", id))
+
+	with, err := utils.CanonicalLanguage(with)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: getAlignedVerses: %w: %w", ErrInvalidInput, err)
+	}
+
+	translation, err := s.pickTranslation(ctx, id, prefs)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: getAlignedVerses: %w", err)
+	}
+
+	left, err := s.getLyrics(ctx, id, translation.Language)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: getAlignedVerses: %w", err)
+	}
+	right, err := s.getLyrics(ctx, id, with)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: getAlignedVerses: %s: %w", with, err)
+	}
+
+	leftVerses, rightVerses := left.Verses(), right.Verses()
+	aligned := make([]models.AlignedVerse, max(len(leftVerses), len(rightVerses)))
+	for i := range aligned {
+		aligned[i] = models.AlignedVerse{Index: i, Verses: map[string]string{}}
+		if i < len(leftVerses) {
+			aligned[i].Verses[left.Language] = leftVerses[i]
+		}
+		if i < len(rightVerses) {
+			aligned[i].Verses[right.Language] = rightVerses[i]
+		}
+	}
+
+	paginated, err := utils.PaginateVerses(aligned, page, limit, log)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: getAlignedVerses: paginateVerses: %w", err)
+	}
+
+	log.Debug("MLibService.GetAlignedVerses success")
+	return paginated, nil
+}
+
+func (s *MLibService) GetTranslations(ctx context.Context, id int) ([]models.Translation, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.GetTranslations func")
+	ctx, span := tracer.Start(ctx, "MLibService.GetTranslations")
+	defer span.End()
+	span.SetAttributes(attribute.Int("song.id", id))
+
+	translations, err := s.translations(ctx, id)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: getTranslations: %w", err)
+	}
+
+	log.Debug("MLibService.GetTranslations success")
+	return translations, nil
+}
+
+// PutTranslation stores the lyrics of a song in the given BCP-47 language.
+func (s *MLibService) PutTranslation(ctx context.Context, id int, lang string, req models.PutTranslation) (models.Lyrics, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.PutTranslation func")
+	ctx, span := tracer.Start(ctx, "MLibService.PutTranslation")
+	defer span.End()
+	span.SetAttributes(attribute.Int("song.id", id), attribute.String("language", lang))
+
+	lang, err := utils.CanonicalLanguage(lang)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return models.Lyrics{}, fmt.Errorf("mlib service: putTranslation: %w: %w", ErrInvalidInput, err)
+	}
+
+	text := utils.NormalizeLyrics(*req.Text)
+	lyrics := utils.ParseLyrics(text)
+	lyrics.SongID = id
+	lyrics.Language = lang
+
+	flags := s.profanity.Classify(text, lang)
+	if err = s.repo.PutTranslation(ctx, id, lang, text, req.Original, lyrics, flags); err != nil {
+		tracing.RecordError(span, err)
+		return models.Lyrics{}, fmt.Errorf("mlib service: putTranslation: repo: %w", err)
+	}
+	// Only original lyrics are queued, so waking the refresh for a translation finds nothing to do.
+	s.requestSimilarityRefresh()
+
+	log.Infof("PutTranslation: Stored %s lyrics for song ID %d", lang, id)
+	return lyrics, nil
+}
+
+func (s *MLibService) DeleteTranslation(ctx context.Context, id int, lang string) error {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.DeleteTranslation func")
+	ctx, span := tracer.Start(ctx, "MLibService.DeleteTranslation")
+	defer span.End()
+	span.SetAttributes(attribute.Int("song.id", id), attribute.String("language", lang))
+
+	lang, err := utils.CanonicalLanguage(lang)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("mlib service: deleteTranslation: %w: %w", ErrInvalidInput, err)
+	}
+
+	if err = s.repo.DeleteTranslation(ctx, id, lang); err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("mlib service: deleteTranslation: repo: %w", err)
+	}
+
+	log.Infof("DeleteTranslation: Deleted %s lyrics of song ID %d", lang, id)
+	return nil
+}
+
+// SetSyncedLyrics replaces the original lyrics of a song with the given LRC or enhanced LRC document.
+func (s *MLibService) SetSyncedLyrics(ctx context.Context, id int, document string) (models.Lyrics, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.SetSyncedLyrics func")
+	ctx, span := tracer.Start(ctx, "MLibService.SetSyncedLyrics")
+	defer span.End()
+	span.SetAttributes(attribute.Int("song.id", id))
+
+	lyrics, err := utils.ParseLRC(document)
+	if err != nil {
+		log.Warnf("SetSyncedLyrics: Invalid LRC for song ID %d: %v", id, err)
+		tracing.RecordError(span, err)
+		return models.Lyrics{}, fmt.Errorf("mlib service: setSyncedLyrics: parse: %w", err)
+	}
+	lyrics.SongID = id
+
+	lang, err := s.originalLanguage(ctx, id)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return models.Lyrics{}, fmt.Errorf("mlib service: setSyncedLyrics: %w", err)
+	}
+	text := lyrics.Text()
+	flags := s.profanity.Classify(text, lang)
+	update := models.SongUpdate{Text: &text, Explicit: &flags.Explicit, ProfanityCount: &flags.ProfanityCount}
+	_, err = s.repo.EditSong(ctx, id, update, &lyrics)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return models.Lyrics{}, fmt.Errorf("mlib service: setSyncedLyrics: repo: %w", err)
+	}
+	metrics.SongsEdited.Inc()
+
+	log.Infof("SetSyncedLyrics: Stored %d sections for song ID %d", len(lyrics.Sections), id)
+	return lyrics, nil
+}
+
+// GetActiveLine returns the line of the time-synced original lyrics being sung offsetMs into the song.
+func (s *MLibService) GetActiveLine(ctx context.Context, id, offsetMs int) (models.ActiveLine, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.GetActiveLine func")
+	ctx, span := tracer.Start(ctx, "MLibService.GetActiveLine")
+	defer span.End()
+	span.SetAttributes(attribute.Int("song.id", id), attribute.Int("offset_ms", offsetMs))
+
+	translation, err := s.pickTranslation(ctx, id, nil)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return models.ActiveLine{}, fmt.Errorf("mlib service: getActiveLine: %w", err)
+	}
+
+	lyrics, err := s.getLyrics(ctx, id, translation.Language)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return models.ActiveLine{}, fmt.Errorf("mlib service: getActiveLine: %w", err)
+	}
+	if !lyrics.Synced() {
+		return models.ActiveLine{}, fmt.Errorf("mlib service: getActiveLine: %w", utils.ErrLyricsNotSynced)
+	}
+
+	active, ok := utils.ActiveLine(lyrics, offsetMs)
+	if !ok {
+		return models.ActiveLine{}, fmt.Errorf("mlib service: getActiveLine: no line at %d ms: %w", offsetMs, ErrNotFound)
+	}
+
+	log.Debug("MLibService.GetActiveLine success")
+	return active, nil
+}
+
+// translations lists the lyrics versions of a song, the original first.
+// A song without any lyrics does not exist.
+func (s *MLibService) translations(ctx context.Context, id int) ([]models.Translation, error) {
+	translations, err := s.repo.GetTranslations(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("repo translations: %w", err)
+	}
+	if len(translations) == 0 {
+		return nil, fmt.Errorf("song %d: %w", id, ErrNotFound)
+	}
+	return translations, nil
+}
+
+// originalLanguage returns the language of the original lyrics of a song, undetermined when it
+// is not known or the song has no lyrics yet.
+func (s *MLibService) originalLanguage(ctx context.Context, id int) (string, error) {
+	translations, err := s.repo.GetTranslations(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("repo translations: %w", err)
+	}
+	if len(translations) == 0 || !translations[0].Original {
+		return models.LanguageUndetermined, nil
+	}
+	return translations[0].Language, nil
+}
+
+// pickTranslation returns the version of the lyrics that best matches the preferred languages.
+// Without a match, or without preferences, it returns the original.
+func (s *MLibService) pickTranslation(ctx context.Context, id int, prefs []language.Tag) (models.Translation, error) {
+	translations, err := s.translations(ctx, id)
+	if err != nil {
+		return models.Translation{}, err
+	}
+	if len(prefs) == 0 || len(translations) == 1 {
+		return translations[0], nil
+	}
+
+	supported := make([]language.Tag, len(translations))
+	for i, translation := range translations {
+		supported[i] = language.Make(translation.Language)
+	}
+	_, index, confidence := language.NewMatcher(supported).Match(prefs...)
+	if confidence == language.No {
+		return translations[0], nil
+	}
+
+	utils.LoggerFromContext(ctx, s.log).Debugf("Picked %s lyrics for song ID %d", translations[index].Language, id)
+	return translations[index], nil
+}
+
+// getLyrics returns the stored sections of the lyrics of a song in the given language, parsing
+// the text of lyrics stored before they were split into sections.
+func (s *MLibService) getLyrics(ctx context.Context, id int, lang string) (models.Lyrics, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+
+	sections, err := s.repo.GetSections(ctx, id, lang)
+	if err != nil {
+		return models.Lyrics{}, fmt.Errorf("repo sections: %w", err)
+	}
+	if len(sections) > 0 {
+		return models.Lyrics{SongID: id, Language: lang, Sections: sections}, nil
+	}
+
+	log.Debugf("No stored %s sections for song ID %d, parsing text", lang, id)
+	text, err := s.repo.GetLyricsText(ctx, id, lang)
+	if err != nil {
+		return models.Lyrics{}, fmt.Errorf("repo: %w", err)
+	}
+
+	lyrics := utils.ParseLyrics(text)
+	lyrics.SongID = id
+	lyrics.Language = lang
+	return lyrics, nil
+}
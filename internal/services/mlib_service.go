@@ -4,160 +4,294 @@ import (
 	"context"
 	"fmt"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/text/language"
+	"music-library/internal/metrics"
 	"music-library/internal/models"
 	"music-library/internal/repositories"
-	pagination "music-library/internal/utils"
+	"music-library/internal/tracing"
+	"music-library/internal/utils"
 )
 
+var tracer = otel.Tracer("music-library/internal/services")
+
 type MLibService struct {
 	repo         repositories.MLibRepository
 	log          *logrus.Logger
 	extAPIClient *ExternalAPIClient
+	profanity    *utils.ProfanityFilter
+
+	// similarityRefresh wakes RefreshSimilarities when a song changes.
+	similarityRefresh chan struct{}
 }
 
-func NewMLibService(repo repositories.MLibRepository, log *logrus.Logger, extAPIClient *ExternalAPIClient) *MLibService {
-	return &MLibService{repo: repo, log: log, extAPIClient: extAPIClient}
+func NewMLibService(repo repositories.MLibRepository, log *logrus.Logger, extAPIClient *ExternalAPIClient, profanity *utils.ProfanityFilter) *MLibService {
+	return &MLibService{
+		repo:              repo,
+		log:               log,
+		extAPIClient:      extAPIClient,
+		profanity:         profanity,
+		similarityRefresh: make(chan struct{}, 1),
+	}
 }
 
-func (s *MLibService) GetLibrary(filter models.LibraryFilter) ([]models.Song, error) {
-	s.log.Debug("Entering MLibService.GetLibrary func")
+func (s *MLibService) GetLibrary(ctx context.Context, filter models.LibraryFilter) ([]models.Song, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.GetLibrary func")
+	ctx, span := tracer.Start(ctx, "MLibService.GetLibrary")
+	defer span.End()
 
 	if filter.Page != nil {
 		if *filter.Page < 1 {
 			*filter.Page = 1
-			s.log.Info("Page is less than 1, defaulting to 1")
+			log.Info("Page is less than 1, defaulting to 1")
 		}
 	} else {
 		page := 1
 		filter.Page = &page
-		s.log.Info("Page is nil, defaulting to 1")
+		log.Info("Page is nil, defaulting to 1")
 	}
 
 	if filter.Limit != nil {
 		if *filter.Limit < 1 {
 			*filter.Limit = 10
-			s.log.Info("Limit is less than 1, defaulting to 10")
+			log.Info("Limit is less than 1, defaulting to 10")
 		}
 	} else {
 		limit := 10
 		filter.Limit = &limit
-		s.log.Info("Limit is nil, defaulting to 10")
+		log.Info("Limit is nil, defaulting to 10")
 	}
 
-	filters := make(map[string]interface{})
-
-	if filter.ID != nil {
-		filters["s.id"] = filter.ID
-	}
-	if filter.Group != nil {
-		filters["g.group_name"] = "%" + *filter.Group + "%"
-	}
-	if filter.Song != nil {
-		filters["s.song_name"] = "%" + *filter.Song + "%"
-	}
-	if filter.ReleaseDate != nil {
-		filters["s.release_date"] = *filter.ReleaseDate
+	tags, err := utils.ParseTags(filter.Tags)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: getLib: %w: %w", ErrInvalidInput, err)
 	}
-	if filter.Text != nil {
-		filters["s.text"] = "%" + *filter.Text + "%"
+	filter.Tags = tags
+	switch filter.TagMatch {
+	case "":
+		filter.TagMatch = models.TagMatchAny
+	case models.TagMatchAny, models.TagMatchAll:
+	default:
+		err = fmt.Errorf("tagMatch must be %q or %q, got %q", models.TagMatchAny, models.TagMatchAll, filter.TagMatch)
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: getLib: %w: %w", ErrInvalidInput, err)
 	}
-	if filter.Link != nil {
-		filters["s.link"] = *filter.Link
+	switch filter.Sort {
+	case "":
+		filter.Sort = models.SortID
+	case models.SortID, models.SortPopular:
+	default:
+		err = fmt.Errorf("sort must be %q or %q, got %q", models.SortID, models.SortPopular, filter.Sort)
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: getLib: %w: %w", ErrInvalidInput, err)
 	}
 
-	songs, err := s.repo.GetLibrary(context.Background(), filters, *filter.Page, *filter.Limit)
+	songs, err := s.repo.GetLibrary(ctx, filter, *filter.Page, *filter.Limit)
 	if err != nil {
-		s.log.Debug("MLibService.GetLibrary err")
-		return nil, fmt.Errorf("mlib service: getLib: repo: ", err)
+		log.Debug("MLibService.GetLibrary err")
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: getLib: repo: %w", err)
 	}
-	s.log.Debug("MLibService.GetLibrary success")
+	log.Debug("MLibService.GetLibrary success")
 
 	return songs, nil
 }
 
-func (s *MLibService) GetText(id, page, limit int) ([]string, error) {
-	s.log.Debug("Entering MLibService.GetText func")
+// GetText returns a page of verses of the lyrics in the best match for the preferred languages
+// together with the language picked. With censor, explicit words in the verses are masked.
+func (s *MLibService) GetText(ctx context.Context, id, page, limit int, prefs []language.Tag, censor bool) ([]string, string, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.GetText func")
+	ctx, span := tracer.Start(ctx, "MLibService.GetText")
+	defer span.End()
+	span.SetAttributes(attribute.Int("song.id", id))
 
-	text, err := s.repo.GetText(context.Background(), id)
+	translation, err := s.pickTranslation(ctx, id, prefs)
 	if err != nil {
-		return nil, fmt.Errorf("mlib service: getText: repo: ", err)
+		tracing.RecordError(span, err)
+		return nil, "", fmt.Errorf("mlib service: getText: %w", err)
 	}
 
-	verses, err := pagination.PaginateVerses(text, page, limit, s.log)
+	lyrics, err := s.getLyrics(ctx, id, translation.Language)
 	if err != nil {
-		return nil, fmt.Errorf("mlib service: getText: paginateVerses: repo: ", err)
+		tracing.RecordError(span, err)
+		return nil, "", fmt.Errorf("mlib service: getText: %w", err)
 	}
 
-	s.log.Debug("MLibService.GetText success")
-	return verses, nil
+	verses, err := utils.PaginateVerses(lyrics.Verses(), page, limit, log)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, "", fmt.Errorf("mlib service: getText: paginateVerses: repo: %w", err)
+	}
+	if censor {
+		censored := make([]string, len(verses))
+		for i, verse := range verses {
+			censored[i] = s.profanity.Censor(verse, lyrics.Language)
+		}
+		verses = censored
+	}
+
+	log.Debug("MLibService.GetText success")
+	return verses, lyrics.Language, nil
 }
 
-func (s *MLibService) DeleteSong(id int) error {
-	s.log.Debug("Entering MLibService.DeleteSong func")
+func (s *MLibService) DeleteSong(ctx context.Context, id int) error {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.DeleteSong func")
+	ctx, span := tracer.Start(ctx, "MLibService.DeleteSong")
+	defer span.End()
+	span.SetAttributes(attribute.Int("song.id", id))
 
-	err := s.repo.DeleteSong(context.Background(), id)
+	err := s.repo.DeleteSong(ctx, id)
 	if err != nil {
-		return fmt.Errorf("mlib service: DeleteSong: repo: ", err)
+		tracing.RecordError(span, err)
+		return fmt.Errorf("mlib service: DeleteSong: repo: %w", err)
 	}
+	metrics.SongsDeleted.Inc()
+	s.requestSimilarityRefresh()
 
-	s.log.Debug("MLibService.DeleteSong success")
+	log.Debug("MLibService.DeleteSong success")
 
 	return nil
 }
 
-func (s *MLibService) EditSong(id int, req models.EditSong) error {
-	updates := make(map[string]interface{})
+// EditSong applies the changes in req to a song and returns the edited song.
+func (s *MLibService) EditSong(ctx context.Context, id int, req models.EditSong) (models.Song, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	ctx, span := tracer.Start(ctx, "MLibService.EditSong")
+	defer span.End()
+	span.SetAttributes(attribute.Int("song.id", id))
 
-	if req.Group != nil {
-		updates["group_name"] = *req.Group
-	}
-	if req.Song != nil {
-		updates["song_name"] = *req.Song
+	update, lyrics, err := s.songUpdate(ctx, id, req)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return models.Song{}, fmt.Errorf("mlib service: EditSong: %w", err)
 	}
-	if req.ReleaseDate != nil {
-		updates["release_date"] = *req.ReleaseDate
+	if update.Empty() {
+		log.Infof("EditSong called with no updates for song ID: %d", id)
+		song, err := s.repo.GetSong(ctx, id)
+		if err != nil {
+			tracing.RecordError(span, err)
+			return models.Song{}, fmt.Errorf("mlib service: EditSong: repo: %w", err)
+		}
+		return song, nil
 	}
-	if req.Text != nil {
-		updates["text"] = *req.Text
+
+	log.WithFields(utils.UpdateFields(update)).Debugf("EditSong: Preparing to update song ID %d", id)
+
+	song, err := s.repo.EditSong(ctx, id, update, lyrics)
+	if err != nil {
+		tracing.RecordError(span, err)
+		log.Errorf("EditSong: Failed to update song ID %d: %v", id, err)
+		return models.Song{}, fmt.Errorf("mlib service: EditSong: repo: %w", err)
 	}
-	if req.Link != nil {
-		updates["link"] = *req.Link
+	metrics.SongsEdited.Inc()
+	s.requestSimilarityRefresh()
+
+	log.Infof("EditSong: Successfully updated song ID %d", id)
+	return song, nil
+}
+
+// songUpdate converts an edit request for a song into a song update. When the text changes, it
+// is normalized and classified in the language of the original lyrics and the parsed lyrics are
+// returned as well.
+func (s *MLibService) songUpdate(ctx context.Context, id int, req models.EditSong) (models.SongUpdate, *models.Lyrics, error) {
+	update := models.SongUpdate{
+		Group:       req.Group,
+		Song:        req.Song,
+		ReleaseDate: req.ReleaseDate,
+		Link:        req.Link,
 	}
+	var lyrics *models.Lyrics
+	if req.Text != nil {
+		lang, err := s.originalLanguage(ctx, id)
+		if err != nil {
+			return models.SongUpdate{}, nil, err
+		}
+		text := utils.NormalizeLyrics(*req.Text)
+		parsed := utils.ParseLyrics(text)
+		update.Text = &text
+		lyrics = &parsed
 
-	if len(updates) == 0 {
-		s.log.Infof("EditSong called with no updates for song ID: %d", id)
-		return nil
+		flags := s.profanity.Classify(text, lang)
+		update.Explicit = &flags.Explicit
+		update.ProfanityCount = &flags.ProfanityCount
 	}
+	return update, lyrics, nil
+}
 
-	s.log.Debugf("EditSong: Preparing to update song ID %d with updates: %+v", id, updates)
+// AddSong completes a new song with its details from the external API, stores it and returns it
+// with its ID.
+func (s *MLibService) AddSong(ctx context.Context, song models.Song) (models.Song, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.WithFields(utils.SongFields(song)).Info("AddSong: Adding new song")
+	ctx, span := tracer.Start(ctx, "MLibService.AddSong")
+	defer span.End()
 
-	err := s.repo.EditSong(context.Background(), id, updates)
+	song, lyrics, err := s.prepareSong(ctx, song)
 	if err != nil {
-		s.log.Errorf("EditSong: Failed to update song ID %d: %v", id, err)
-		return fmt.Errorf("mlib service: EditSong: repo: %w", err)
+		tracing.RecordError(span, err)
+		return models.Song{}, fmt.Errorf("mlib service: AddSong: %w", err)
 	}
 
-	s.log.Infof("EditSong: Successfully updated song ID %d", id)
-	return nil
+	added, err := s.repo.AddSong(ctx, song, lyrics)
+	if err != nil {
+		tracing.RecordError(span, err)
+		log.WithFields(utils.SongFields(song)).Errorf("AddSong: Failed to add song to repository, error: %v", err)
+		return models.Song{}, fmt.Errorf("mlib_service: AddSong: repo: %w", err)
+	}
+	metrics.SongsAdded.Inc()
+	s.requestSimilarityRefresh()
+
+	log.WithFields(utils.SongFields(added)).Info("AddSong: Successfully added new song")
+	return added, nil
 }
 
-func (s *MLibService) AddSong(song models.Song) error {
-	s.log.Infof("AddSong: Adding new song: %+v", song)
+// prepareSong completes a new song with its details from the external API, normalizes its text
+// and derives the parsed lyrics and content flags.
+func (s *MLibService) prepareSong(ctx context.Context, song models.Song) (models.Song, models.Lyrics, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
 
-	err := s.extAPIClient.GetSongDetails(&song)
+	err := s.extAPIClient.GetSongDetails(ctx, &song)
 	if err != nil {
-		s.log.Errorf("AddSong: Failed to get song details for: %+v, error: %v", song, err)
-		return fmt.Errorf("mlib service: AddSong: GetSongDetails: %w", err)
+		log.WithFields(utils.SongFields(song)).Errorf("AddSong: Failed to get song details, error: %v", err)
+		return song, models.Lyrics{}, fmt.Errorf("GetSongDetails: %w", err)
 	}
 
-	s.log.Debugf("AddSong: Retrieved song details: %+v", song)
+	log.WithFields(utils.SongFields(song)).Debug("AddSong: Retrieved song details")
 
-	err = s.repo.AddSong(context.Background(), song)
-	if err != nil {
-		s.log.Errorf("AddSong: Failed to add song to repository: %+v, error: %v", song, err)
-		return fmt.Errorf("mlib_service: AddSong: repo: %w", err)
+	song, lyrics := s.analyzeSong(ctx, song)
+	return song, lyrics, nil
+}
+
+// analyzeSong normalizes the text and genre of a new song and derives its parsed lyrics and
+// content flags. A genre that is not a valid tag is dropped.
+func (s *MLibService) analyzeSong(ctx context.Context, song models.Song) (models.Song, models.Lyrics) {
+	if song.Genre != nil {
+		genre, err := utils.NormalizeTag(*song.Genre)
+		if err != nil {
+			utils.LoggerFromContext(ctx, s.log).WithFields(utils.SongFields(song)).Warnf("Ignoring genre: %v", err)
+			song.Genre = nil
+		} else {
+			song.Genre = &genre
+		}
 	}
 
-	s.log.Infof("AddSong: Successfully added new song: %+v", song)
-	return nil
+	// The content flags are always computed here, never taken from the song details. The
+	// language of new lyrics is not known, so every word list applies.
+	song.Explicit, song.ProfanityCount = nil, nil
+	var lyrics models.Lyrics
+	if song.Text != nil {
+		text := utils.NormalizeLyrics(*song.Text)
+		song.Text = &text
+		lyrics = utils.ParseLyrics(text)
+
+		flags := s.profanity.Classify(text, models.LanguageUndetermined)
+		song.Explicit = &flags.Explicit
+		song.ProfanityCount = &flags.ProfanityCount
+	}
+	return song, lyrics
 }
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"music-library/internal/models"
+	"music-library/internal/tracing"
+	"music-library/internal/utils"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxPlaylistNameLength is the longest playlist name, in characters.
+const maxPlaylistNameLength = 255
+
+// currentUser returns the user making the request, who owns the playlists it creates and may
+// change only those.
+func currentUser(ctx context.Context) (string, error) {
+	user := utils.UserFromContext(ctx)
+	if user == nil {
+		return "", fmt.Errorf("%w: the request has no user", ErrUnauthorized)
+	}
+	return *user, nil
+}
+
+// playlistDetails validates and trims the name and description of a playlist. An empty
+// description is dropped.
+func playlistDetails(input models.PlaylistInput) (string, *string, error) {
+	if input.Name == nil {
+		return "", nil, fmt.Errorf("%w: name is required", ErrInvalidInput)
+	}
+	name := strings.TrimSpace(*input.Name)
+	if name == "" {
+		return "", nil, fmt.Errorf("%w: name is empty", ErrInvalidInput)
+	}
+	if utf8.RuneCountInString(name) > maxPlaylistNameLength {
+		return "", nil, fmt.Errorf("%w: name is longer than %d characters", ErrInvalidInput, maxPlaylistNameLength)
+	}
+
+	var description *string
+	if input.Description != nil {
+		if trimmed := strings.TrimSpace(*input.Description); trimmed != "" {
+			description = &trimmed
+		}
+	}
+	return name, description, nil
+}
+
+// CreatePlaylist creates an empty playlist owned by the user making the request.
+func (s *MLibService) CreatePlaylist(ctx context.Context, input models.PlaylistInput) (models.Playlist, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.CreatePlaylist func")
+	ctx, span := tracer.Start(ctx, "MLibService.CreatePlaylist")
+	defer span.End()
+
+	owner, err := currentUser(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return models.Playlist{}, fmt.Errorf("mlib service: createPlaylist: %w", err)
+	}
+	name, description, err := playlistDetails(input)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return models.Playlist{}, fmt.Errorf("mlib service: createPlaylist: %w", err)
+	}
+
+	playlist, err := s.repo.CreatePlaylist(ctx, owner, name, description)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return models.Playlist{}, fmt.Errorf("mlib service: createPlaylist: repo: %w", err)
+	}
+
+	log.Infof("CreatePlaylist: Created playlist ID %d", playlist.ID)
+	return playlist, nil
+}
+
+// GetPlaylists returns the playlists of the user making the request.
+func (s *MLibService) GetPlaylists(ctx context.Context) ([]models.Playlist, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.GetPlaylists func")
+	ctx, span := tracer.Start(ctx, "MLibService.GetPlaylists")
+	defer span.End()
+
+	owner, err := currentUser(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: getPlaylists: %w", err)
+	}
+
+	playlists, err := s.repo.GetPlaylists(ctx, owner)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: getPlaylists: repo: %w", err)
+	}
+	if playlists == nil {
+		playlists = []models.Playlist{}
+	}
+	return playlists, nil
+}
+
+// GetPlaylist returns a playlist with a page of its items. Any user may read a playlist.
+func (s *MLibService) GetPlaylist(ctx context.Context, id, page, limit int) (models.PlaylistPage, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.GetPlaylist func")
+	ctx, span := tracer.Start(ctx, "MLibService.GetPlaylist")
+	defer span.End()
+	span.SetAttributes(attribute.Int("playlist.id", id))
+
+	if page < 1 {
+		page = 1
+		log.Info("Page is less than 1, defaulting to 1")
+	}
+	if limit < 1 {
+		limit = 10
+		log.Info("Limit is less than 1, defaulting to 10")
+	}
+
+	playlist, err := s.repo.GetPlaylist(ctx, id, page, limit)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return models.PlaylistPage{}, fmt.Errorf("mlib service: getPlaylist: repo: %w", err)
+	}
+	return playlist, nil
+}
+
+// UpdatePlaylist replaces the name and description of a playlist of the user making the request.
+func (s *MLibService) UpdatePlaylist(ctx context.Context, id int, input models.PlaylistInput) (models.Playlist, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.UpdatePlaylist func")
+	ctx, span := tracer.Start(ctx, "MLibService.UpdatePlaylist")
+	defer span.End()
+	span.SetAttributes(attribute.Int("playlist.id", id))
+
+	owner, err := currentUser(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return models.Playlist{}, fmt.Errorf("mlib service: updatePlaylist: %w", err)
+	}
+	name, description, err := playlistDetails(input)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return models.Playlist{}, fmt.Errorf("mlib service: updatePlaylist: %w", err)
+	}
+
+	playlist, err := s.repo.UpdatePlaylist(ctx, id, owner, name, description)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return models.Playlist{}, fmt.Errorf("mlib service: updatePlaylist: repo: %w", err)
+	}
+
+	log.Infof("UpdatePlaylist: Updated playlist ID %d", id)
+	return playlist, nil
+}
+
+// DeletePlaylist deletes a playlist of the user making the request.
+func (s *MLibService) DeletePlaylist(ctx context.Context, id int) error {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.DeletePlaylist func")
+	ctx, span := tracer.Start(ctx, "MLibService.DeletePlaylist")
+	defer span.End()
+	span.SetAttributes(attribute.Int("playlist.id", id))
+
+	owner, err := currentUser(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("mlib service: deletePlaylist: %w", err)
+	}
+
+	if err = s.repo.DeletePlaylist(ctx, id, owner); err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("mlib service: deletePlaylist: repo: %w", err)
+	}
+
+	log.Infof("DeletePlaylist: Deleted playlist ID %d", id)
+	return nil
+}
+
+// AddPlaylistItem adds a song to a playlist of the user making the request.
+func (s *MLibService) AddPlaylistItem(ctx context.Context, id int, input models.AddPlaylistItem) (models.PlaylistItem, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.AddPlaylistItem func")
+	ctx, span := tracer.Start(ctx, "MLibService.AddPlaylistItem")
+	defer span.End()
+	span.SetAttributes(attribute.Int("playlist.id", id))
+
+	owner, err := currentUser(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return models.PlaylistItem{}, fmt.Errorf("mlib service: addPlaylistItem: %w", err)
+	}
+	if input.SongID == nil {
+		return models.PlaylistItem{}, fmt.Errorf("mlib service: addPlaylistItem: %w: songId is required", ErrInvalidInput)
+	}
+	if input.Position != nil && *input.Position < 1 {
+		return models.PlaylistItem{}, fmt.Errorf("mlib service: addPlaylistItem: %w: position must be at least 1", ErrInvalidInput)
+	}
+	span.SetAttributes(attribute.Int("song.id", *input.SongID))
+
+	item, err := s.repo.AddPlaylistItem(ctx, id, owner, *input.SongID, input.Position)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return models.PlaylistItem{}, fmt.Errorf("mlib service: addPlaylistItem: repo: %w", err)
+	}
+
+	log.Infof("AddPlaylistItem: Added song ID %d to playlist ID %d at position %d", *input.SongID, id, item.Position)
+	return item, nil
+}
+
+// RemovePlaylistItem removes an item from a playlist of the user making the request.
+func (s *MLibService) RemovePlaylistItem(ctx context.Context, id, itemId int) error {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.RemovePlaylistItem func")
+	ctx, span := tracer.Start(ctx, "MLibService.RemovePlaylistItem")
+	defer span.End()
+	span.SetAttributes(attribute.Int("playlist.id", id), attribute.Int("playlist.item.id", itemId))
+
+	owner, err := currentUser(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("mlib service: removePlaylistItem: %w", err)
+	}
+
+	if err = s.repo.RemovePlaylistItem(ctx, id, owner, itemId); err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("mlib service: removePlaylistItem: repo: %w", err)
+	}
+
+	log.Infof("RemovePlaylistItem: Removed item ID %d from playlist ID %d", itemId, id)
+	return nil
+}
+
+// MovePlaylistItem moves an item of a playlist of the user making the request to a new position.
+func (s *MLibService) MovePlaylistItem(ctx context.Context, id, itemId int, input models.MovePlaylistItem) (models.PlaylistItem, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.MovePlaylistItem func")
+	ctx, span := tracer.Start(ctx, "MLibService.MovePlaylistItem")
+	defer span.End()
+	span.SetAttributes(attribute.Int("playlist.id", id), attribute.Int("playlist.item.id", itemId))
+
+	owner, err := currentUser(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return models.PlaylistItem{}, fmt.Errorf("mlib service: movePlaylistItem: %w", err)
+	}
+	if input.Position == nil || *input.Position < 1 {
+		return models.PlaylistItem{}, fmt.Errorf("mlib service: movePlaylistItem: %w: position must be at least 1", ErrInvalidInput)
+	}
+
+	item, err := s.repo.MovePlaylistItem(ctx, id, owner, itemId, *input.Position)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return models.PlaylistItem{}, fmt.Errorf("mlib service: movePlaylistItem: repo: %w", err)
+	}
+
+	log.Infof("MovePlaylistItem: Moved item ID %d of playlist ID %d to position %d", itemId, id, item.Position)
+	return item, nil
+}
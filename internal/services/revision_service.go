@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"music-library/internal/models"
+	"music-library/internal/tracing"
+	"music-library/internal/utils"
+	"strconv"
+)
+
+// CurrentRevision names the latest revision of the lyrics of a song.
+const CurrentRevision = "current"
+
+// GetLyricsDiff compares two revisions of the original lyrics of a song by line or by verse. Revisions are
+// numbers or "current"; to defaults to the current revision and from to the revision before to.
+func (s *MLibService) GetLyricsDiff(ctx context.Context, id int, from, to, granularity string) (models.LyricsDiff, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.GetLyricsDiff func")
+	ctx, span := tracer.Start(ctx, "MLibService.GetLyricsDiff")
+	defer span.End()
+	span.SetAttributes(attribute.Int("song.id", id))
+
+	if err := validGranularity(granularity); err != nil {
+		tracing.RecordError(span, err)
+		return models.LyricsDiff{}, fmt.Errorf("mlib service: getLyricsDiff: %w", err)
+	}
+
+	if to == "" {
+		to = CurrentRevision
+	}
+	toNumber, err := parseRevision(to)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return models.LyricsDiff{}, fmt.Errorf("mlib service: getLyricsDiff: to: %w", err)
+	}
+	var fromNumber *int
+	if from != "" {
+		if fromNumber, err = parseRevision(from); err != nil {
+			tracing.RecordError(span, err)
+			return models.LyricsDiff{}, fmt.Errorf("mlib service: getLyricsDiff: from: %w", err)
+		}
+	}
+
+	newer, err := s.repo.GetRevision(ctx, id, toNumber)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return models.LyricsDiff{}, fmt.Errorf("mlib service: getLyricsDiff: to: %w", err)
+	}
+
+	// Revisions are numbered from 1 without gaps, and the first revision is compared against
+	// empty lyrics.
+	if from == "" && newer.Revision > 1 {
+		previous := newer.Revision - 1
+		fromNumber = &previous
+	}
+	var old models.SongRevision
+	if fromNumber != nil || from == CurrentRevision {
+		if old, err = s.repo.GetRevision(ctx, id, fromNumber); err != nil {
+			tracing.RecordError(span, err)
+			return models.LyricsDiff{}, fmt.Errorf("mlib service: getLyricsDiff: from: %w", err)
+		}
+	}
+
+	diff, err := lyricsDiff(id, old.Text, newer.Text, granularity)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return models.LyricsDiff{}, fmt.Errorf("mlib service: getLyricsDiff: %w", err)
+	}
+	diff.From = strconv.Itoa(old.Revision)
+	diff.To = strconv.Itoa(newer.Revision)
+
+	log.Debug("MLibService.GetLyricsDiff success")
+	return diff, nil
+}
+
+// DiffProposedLyrics compares the current original lyrics of a song with proposed lyrics without storing them.
+func (s *MLibService) DiffProposedLyrics(ctx context.Context, id int, text, granularity string) (models.LyricsDiff, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.DiffProposedLyrics func")
+	ctx, span := tracer.Start(ctx, "MLibService.DiffProposedLyrics")
+	defer span.End()
+	span.SetAttributes(attribute.Int("song.id", id))
+
+	if err := validGranularity(granularity); err != nil {
+		tracing.RecordError(span, err)
+		return models.LyricsDiff{}, fmt.Errorf("mlib service: diffProposedLyrics: %w", err)
+	}
+
+	current, err := s.repo.GetRevision(ctx, id, nil)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return models.LyricsDiff{}, fmt.Errorf("mlib service: diffProposedLyrics: %w", err)
+	}
+
+	diff, err := lyricsDiff(id, current.Text, text, granularity)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return models.LyricsDiff{}, fmt.Errorf("mlib service: diffProposedLyrics: %w", err)
+	}
+	diff.From = strconv.Itoa(current.Revision)
+	diff.To = "proposed"
+
+	log.Debug("MLibService.DiffProposedLyrics success")
+	return diff, nil
+}
+
+// GetHistory returns a page of the revisions of the original lyrics of a song, newest first, each
+// with the changes it made to the previous revision. A revision too long to compare with the
+// previous one is marked TooLarge and listed without its changes.
+func (s *MLibService) GetHistory(ctx context.Context, id int, granularity string, page, limit int) ([]models.HistoryEntry, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.GetHistory func")
+	ctx, span := tracer.Start(ctx, "MLibService.GetHistory")
+	defer span.End()
+	span.SetAttributes(attribute.Int("song.id", id))
+
+	if err := validGranularity(granularity); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: getHistory: %w", err)
+	}
+	if page < 1 {
+		page = 1
+		log.Info("Page is less than 1, defaulting to 1")
+	}
+	if limit < 1 {
+		limit = 10
+		log.Info("Limit is less than 1, defaulting to 10")
+	}
+
+	// The revisions come newest first, with the one before the page last when there is one.
+	revisions, err := s.repo.GetRevisionHistory(ctx, id, page, limit)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: getHistory: %w", err)
+	}
+
+	history := make([]models.HistoryEntry, 0, min(len(revisions), limit))
+	for i := 0; i < len(revisions) && i < limit; i++ {
+		previous := ""
+		if i+1 < len(revisions) {
+			previous = revisions[i+1].Text
+		}
+		entry := models.HistoryEntry{SongRevision: revisions[i], Hunks: []models.DiffHunk{}}
+		diff, err := lyricsDiff(id, previous, revisions[i].Text, granularity)
+		switch {
+		case errors.Is(err, utils.ErrDiffTooLarge):
+			log.Infof("Revision %d of song ID %d is too large to compare", revisions[i].Revision, id)
+			entry.TooLarge = true
+		case err != nil:
+			tracing.RecordError(span, err)
+			return nil, fmt.Errorf("mlib service: getHistory: revision %d: %w", revisions[i].Revision, err)
+		default:
+			entry.Added, entry.Removed, entry.Hunks = diff.Added, diff.Removed, diff.Hunks
+		}
+		history = append(history, entry)
+	}
+
+	log.Debug("MLibService.GetHistory success")
+	return history, nil
+}
+
+func lyricsDiff(id int, old, new, granularity string) (models.LyricsDiff, error) {
+	hunks, added, removed, err := utils.Diff(utils.DiffUnits(old, granularity), utils.DiffUnits(new, granularity))
+	if err != nil {
+		return models.LyricsDiff{}, err
+	}
+	if hunks == nil {
+		hunks = []models.DiffHunk{}
+	}
+	return models.LyricsDiff{SongID: id, Granularity: granularity, Added: added, Removed: removed, Hunks: hunks}, nil
+}
+
+func validGranularity(granularity string) error {
+	if granularity != utils.DiffByLine && granularity != utils.DiffByVerse {
+		return fmt.Errorf("%w: granularity must be one of %s, %s", ErrInvalidInput, utils.DiffByLine, utils.DiffByVerse)
+	}
+	return nil
+}
+
+// parseRevision reads a revision number or "current", which is nil.
+func parseRevision(revision string) (*int, error) {
+	if revision == CurrentRevision {
+		return nil, nil
+	}
+	number, err := strconv.Atoi(revision)
+	if err != nil {
+		return nil, fmt.Errorf("%w: revision must be a number or %q", ErrInvalidInput, CurrentRevision)
+	}
+	return &number, nil
+}
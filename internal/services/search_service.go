@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/text/language"
+	"music-library/internal/models"
+	"music-library/internal/tracing"
+	"music-library/internal/utils"
+	"strings"
+)
+
+// SearchVerses returns the verses of the lyrics of a song in the best match for the preferred
+// languages that contain phrase, numbered as served by GetText with pages of pageSize verses.
+func (s *MLibService) SearchVerses(ctx context.Context, id int, phrase string, pageSize int, prefs []language.Tag) ([]models.VerseMatch, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.SearchVerses func")
+	ctx, span := tracer.Start(ctx, "MLibService.SearchVerses")
+	defer span.End()
+	span.SetAttributes(attribute.Int("song.id", id))
+
+	if strings.TrimSpace(phrase) == "" {
+		err := fmt.Errorf("%w: q must not be empty", ErrInvalidInput)
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: searchVerses: %w", err)
+	}
+
+	translation, err := s.pickTranslation(ctx, id, prefs)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: searchVerses: %w", err)
+	}
+
+	lyrics, err := s.getLyrics(ctx, id, translation.Language)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: searchVerses: %w", err)
+	}
+
+	matches := utils.SearchVerses(id, lyrics.Verses(), phrase, pageSize)
+
+	log.Debugf("MLibService.SearchVerses success, %d verses matched", len(matches))
+	return matches, nil
+}
+
+// SearchLibraryVerses searches the original lyrics of a page of songs containing phrase and returns
+// their matching verses. Songs matching only across verses are left out.
+func (s *MLibService) SearchLibraryVerses(ctx context.Context, phrase string, page, limit, pageSize int) ([]models.VerseMatch, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.SearchLibraryVerses func")
+	ctx, span := tracer.Start(ctx, "MLibService.SearchLibraryVerses")
+	defer span.End()
+
+	if strings.TrimSpace(phrase) == "" {
+		err := fmt.Errorf("%w: q must not be empty", ErrInvalidInput)
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: searchLibraryVerses: %w", err)
+	}
+	if page < 1 {
+		page = 1
+		log.Info("Page is less than 1, defaulting to 1")
+	}
+	if limit < 1 {
+		limit = 10
+		log.Info("Limit is less than 1, defaulting to 10")
+	}
+
+	songs, err := s.repo.SearchText(ctx, phrase, page, limit)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: searchLibraryVerses: %w", err)
+	}
+
+	ids := make([]int, len(songs))
+	for i, song := range songs {
+		ids[i] = *song.ID
+	}
+	sections, err := s.repo.GetOriginalSections(ctx, ids)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: searchLibraryVerses: %w", err)
+	}
+
+	// The verses are those of the stored sections, as GET /songs/{id}/lyrics returns them, and
+	// lyrics stored before they were split into sections are parsed.
+	matches := []models.VerseMatch{}
+	for _, song := range songs {
+		lyrics := models.Lyrics{Sections: sections[*song.ID]}
+		if len(lyrics.Sections) == 0 {
+			lyrics = utils.ParseLyrics(*song.Text)
+		}
+		matches = append(matches, utils.SearchVerses(*song.ID, lyrics.Verses(), phrase, pageSize)...)
+	}
+
+	log.Debugf("MLibService.SearchLibraryVerses success, %d verses matched in %d songs", len(matches), len(songs))
+	return matches, nil
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"music-library/internal/models"
+	"music-library/internal/tracing"
+	"music-library/internal/utils"
+)
+
+// seedBatchSize is the number of seed songs inserted per transaction.
+const seedBatchSize = 500
+
+// Seed stores the songs that are not in the library yet, matching them by group and song name,
+// and returns how many were added and skipped. Seed songs are complete, so the external API is
+// not called. Songs are committed in batches, so an interrupted run is resumed by running it again.
+func (s *MLibService) Seed(ctx context.Context, songs []models.Song) (added, skipped int, err error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.Seed func")
+	ctx, span := tracer.Start(ctx, "MLibService.Seed")
+	defer span.End()
+	span.SetAttributes(attribute.Int("seed.songs", len(songs)))
+
+	for start := 0; start < len(songs); start += seedBatchSize {
+		end := min(start+seedBatchSize, len(songs))
+		a, err := s.seedBatch(ctx, songs[start:end])
+		if err != nil {
+			tracing.RecordError(span, err)
+			return added, skipped, fmt.Errorf("mlib service: seed: songs %d-%d: %w", start+1, end, err)
+		}
+		added += a
+		skipped += end - start - a
+		log.Infof("Seed: Processed %d of %d songs", end, len(songs))
+	}
+	return added, skipped, nil
+}
+
+// seedBatch stores the missing songs of one batch in a single transaction.
+func (s *MLibService) seedBatch(ctx context.Context, songs []models.Song) (int, error) {
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	added := 0
+	for _, song := range songs {
+		exists, err := s.repo.SongExistsTx(ctx, tx, *song.Group, *song.Song)
+		if err != nil {
+			return 0, err
+		}
+		if exists {
+			continue
+		}
+
+		song, lyrics := s.analyzeSong(ctx, song)
+		if _, err = s.repo.AddSongTx(ctx, tx, song, lyrics); err != nil {
+			return 0, fmt.Errorf("song %q: %w", *song.Song, err)
+		}
+		added++
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit: %w", err)
+	}
+	return added, nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"music-library/internal/models"
+	"music-library/internal/tracing"
+	"music-library/internal/utils"
+	"time"
+)
+
+// similarSongsKept is the number of similar songs stored for each song.
+const similarSongsKept = 20
+
+// GetSimilarSongs returns up to top of the songs most similar to a song, as last computed by
+// RefreshSimilarities.
+func (s *MLibService) GetSimilarSongs(ctx context.Context, id, top int) ([]models.SimilarSong, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.GetSimilarSongs func")
+	ctx, span := tracer.Start(ctx, "MLibService.GetSimilarSongs")
+	defer span.End()
+	span.SetAttributes(attribute.Int("song.id", id))
+
+	if top < 1 {
+		top = 10
+		log.Info("Top is less than 1, defaulting to 10")
+	}
+	if top > similarSongsKept {
+		top = similarSongsKept
+		log.Infof("Top is more than the %d similar songs stored, lowering it", similarSongsKept)
+	}
+
+	songs, err := s.repo.GetSimilarSongs(ctx, id, top)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: getSimilarSongs: repo: %w", err)
+	}
+	if songs == nil {
+		songs = []models.SimilarSong{}
+	}
+	return songs, nil
+}
+
+// requestSimilarityRefresh wakes RefreshSimilarities without waiting for its next tick.
+func (s *MLibService) requestSimilarityRefresh() {
+	select {
+	case s.similarityRefresh <- struct{}{}:
+	default:
+	}
+}
+
+// RefreshSimilarities recomputes the similar songs of the queued songs every interval, or sooner
+// when a song is added or edited, until ctx is done.
+func (s *MLibService) RefreshSimilarities(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.similarityRefresh:
+		}
+
+		refreshed, err := s.refreshSimilarities(ctx)
+		if err != nil {
+			s.log.Errorf("Failed to refresh similar songs: %v", err)
+		}
+		if refreshed > 0 {
+			s.log.Infof("Refreshed similar songs of %d songs", refreshed)
+		}
+	}
+}
+
+// refreshSimilarities recomputes the queued songs against one load of the library and returns the
+// number of songs refreshed. A song that fails stays queued for the next run.
+func (s *MLibService) refreshSimilarities(ctx context.Context) (int, error) {
+	ctx, span := tracer.Start(ctx, "MLibService.refreshSimilarities")
+	defer span.End()
+
+	jobs, songs, err := s.repo.GetSimilarityWork(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("mlib service: refreshSimilarities: repo: %w", err)
+	}
+	if len(jobs) == 0 {
+		return 0, nil
+	}
+	index := utils.NewSimilarityIndex(songs)
+
+	refreshed := 0
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			break
+		}
+		similar := index.Similar(job.SongID, similarSongsKept)
+		if err = s.repo.SaveSimilarities(ctx, job, similar, similarSongsKept); err != nil {
+			s.log.Errorf("Failed to save similar songs of song ID %d: %v", job.SongID, err)
+			continue
+		}
+		refreshed++
+	}
+	span.SetAttributes(attribute.Int("songs.refreshed", refreshed))
+
+	if refreshed < len(jobs) {
+		err = fmt.Errorf("mlib service: refreshSimilarities: %d of %d songs not refreshed", len(jobs)-refreshed, len(jobs))
+		tracing.RecordError(span, err)
+		return refreshed, err
+	}
+	return refreshed, nil
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/text/language"
+	"music-library/internal/models"
+	"music-library/internal/tracing"
+	"music-library/internal/utils"
+)
+
+// GetSongStats computes statistics of the stored lyrics of a song in the best match for the
+// preferred languages, with up to top most frequent terms.
+func (s *MLibService) GetSongStats(ctx context.Context, id, top int, prefs []language.Tag) (models.SongStats, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.GetSongStats func")
+	ctx, span := tracer.Start(ctx, "MLibService.GetSongStats")
+	defer span.End()
+	span.SetAttributes(attribute.Int("song.id", id))
+
+	if top < 1 {
+		top = 10
+		log.Info("Top is less than 1, defaulting to 10")
+	}
+
+	translation, err := s.pickTranslation(ctx, id, prefs)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return models.SongStats{}, fmt.Errorf("mlib service: getSongStats: %w", err)
+	}
+
+	lyrics, err := s.getLyrics(ctx, id, translation.Language)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return models.SongStats{}, fmt.Errorf("mlib service: getSongStats: %w", err)
+	}
+
+	log.Debug("MLibService.GetSongStats success")
+	return utils.LyricsStats(lyrics, top), nil
+}
+
+// GetLibraryStats aggregates the whole library, listing up to top longest songs and largest groups.
+func (s *MLibService) GetLibraryStats(ctx context.Context, top int) (models.LibraryStats, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.GetLibraryStats func")
+	ctx, span := tracer.Start(ctx, "MLibService.GetLibraryStats")
+	defer span.End()
+
+	if top < 1 {
+		top = 10
+		log.Info("Top is less than 1, defaulting to 10")
+	}
+
+	stats, err := s.repo.GetLibraryStats(ctx, top)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return models.LibraryStats{}, fmt.Errorf("mlib service: getLibraryStats: %w", err)
+	}
+
+	log.Debug("MLibService.GetLibraryStats success")
+	return stats, nil
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"music-library/internal/models"
+	"music-library/internal/tracing"
+	"music-library/internal/utils"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxSuggestQueryLength is the longest query suggestions are searched for, in characters.
+	maxSuggestQueryLength = 100
+	// maxSuggestions is the most suggestions returned at once.
+	maxSuggestions = 50
+)
+
+// Suggest returns up to top of the group or song names, as kind says, best matching what was
+// typed so far, ignoring case and diacritics. kind defaults to models.SuggestSongs.
+func (s *MLibService) Suggest(ctx context.Context, query, kind string, top int) ([]models.Suggestion, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.Suggest func")
+	ctx, span := tracer.Start(ctx, "MLibService.Suggest")
+	defer span.End()
+
+	query = strings.Join(strings.Fields(query), " ")
+	if query == "" {
+		err := fmt.Errorf("%w: q must not be empty", ErrInvalidInput)
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: suggest: %w", err)
+	}
+	if utf8.RuneCountInString(query) > maxSuggestQueryLength {
+		err := fmt.Errorf("%w: q must be at most %d characters", ErrInvalidInput, maxSuggestQueryLength)
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: suggest: %w", err)
+	}
+	switch kind {
+	case "":
+		kind = models.SuggestSongs
+	case models.SuggestGroups, models.SuggestSongs:
+	default:
+		err := fmt.Errorf("%w: type must be %q or %q, got %q", ErrInvalidInput, models.SuggestGroups, models.SuggestSongs, kind)
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: suggest: %w", err)
+	}
+	span.SetAttributes(attribute.String("suggest.type", kind))
+
+	if top < 1 {
+		top = 10
+		log.Info("Top is less than 1, defaulting to 10")
+	}
+	if top > maxSuggestions {
+		top = maxSuggestions
+		log.Infof("Top is more than %d, lowering it", maxSuggestions)
+	}
+
+	suggestions, err := s.repo.Suggest(ctx, kind, query, top)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: suggest: repo: %w", err)
+	}
+	if suggestions == nil {
+		suggestions = []models.Suggestion{}
+	}
+	return suggestions, nil
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"music-library/internal/models"
+	"music-library/internal/tracing"
+	"music-library/internal/utils"
+)
+
+// GetTags returns every tag in use with its number of songs.
+func (s *MLibService) GetTags(ctx context.Context) ([]models.TagCount, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.GetTags func")
+	ctx, span := tracer.Start(ctx, "MLibService.GetTags")
+	defer span.End()
+
+	tags, err := s.repo.GetTags(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: getTags: repo: %w", err)
+	}
+	return tags, nil
+}
+
+// GetSongTags returns the tags of a song.
+func (s *MLibService) GetSongTags(ctx context.Context, id int) ([]string, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.GetSongTags func")
+	ctx, span := tracer.Start(ctx, "MLibService.GetSongTags")
+	defer span.End()
+	span.SetAttributes(attribute.Int("song.id", id))
+
+	tags, err := s.repo.GetSongTags(ctx, id)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: getSongTags: repo: %w", err)
+	}
+	return tags, nil
+}
+
+// AddSongTag tags a song and returns its tags.
+func (s *MLibService) AddSongTag(ctx context.Context, id int, tag string) ([]string, error) {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.AddSongTag func")
+	ctx, span := tracer.Start(ctx, "MLibService.AddSongTag")
+	defer span.End()
+	span.SetAttributes(attribute.Int("song.id", id), attribute.String("tag", tag))
+
+	tag, err := utils.NormalizeTag(tag)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: addSongTag: %w: %w", ErrInvalidInput, err)
+	}
+
+	if err = s.repo.AddSongTag(ctx, id, tag); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("mlib service: addSongTag: repo: %w", err)
+	}
+
+	log.Infof("AddSongTag: Tagged song ID %d with %s", id, tag)
+	return s.GetSongTags(utils.ContextWithPrimary(ctx), id)
+}
+
+// RemoveSongTag removes a tag from a song.
+func (s *MLibService) RemoveSongTag(ctx context.Context, id int, tag string) error {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.RemoveSongTag func")
+	ctx, span := tracer.Start(ctx, "MLibService.RemoveSongTag")
+	defer span.End()
+	span.SetAttributes(attribute.Int("song.id", id), attribute.String("tag", tag))
+
+	tag, err := utils.NormalizeTag(tag)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("mlib service: removeSongTag: %w: %w", ErrInvalidInput, err)
+	}
+
+	if err = s.repo.RemoveSongTag(ctx, id, tag); err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("mlib service: removeSongTag: repo: %w", err)
+	}
+
+	log.Infof("RemoveSongTag: Removed tag %s from song ID %d", tag, id)
+	return nil
+}
+
+// DeleteTag deletes a tag from every song.
+func (s *MLibService) DeleteTag(ctx context.Context, tag string) error {
+	log := utils.LoggerFromContext(ctx, s.log)
+	log.Debug("Entering MLibService.DeleteTag func")
+	ctx, span := tracer.Start(ctx, "MLibService.DeleteTag")
+	defer span.End()
+	span.SetAttributes(attribute.String("tag", tag))
+
+	tag, err := utils.NormalizeTag(tag)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("mlib service: deleteTag: %w: %w", ErrInvalidInput, err)
+	}
+
+	if err = s.repo.DeleteTag(ctx, tag); err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("mlib service: deleteTag: repo: %w", err)
+	}
+
+	log.Infof("DeleteTag: Deleted tag %s", tag)
+	return nil
+}
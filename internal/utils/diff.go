@@ -0,0 +1,224 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"music-library/internal/models"
+	"strings"
+)
+
+const (
+	DiffByLine  = "line"
+	DiffByVerse = "verse"
+
+	diffContext = 3
+
+	// MaxDiffUnits is the most lines or verses compared on either side of a diff.
+	MaxDiffUnits = 5000
+)
+
+var ErrDiffTooLarge = errors.New("lyrics are too long to compare")
+
+// DiffUnits splits lyrics into the units compared by the diff: lines, or verses separated by blank lines.
+func DiffUnits(text, granularity string) []string {
+	normalized := NormalizeLyrics(text)
+	if normalized == "" {
+		return nil
+	}
+	if granularity == DiffByVerse {
+		return sectionSeparator.Split(normalized, -1)
+	}
+	return strings.Split(normalized, "\n")
+}
+
+// Diff compares two sequences of lines or verses and groups the changes into hunks with
+// up to three unchanged units of context around them. It also returns the number of added
+// and removed units. Sequences of more than MaxDiffUnits are ErrDiffTooLarge.
+func Diff(old, new []string) ([]models.DiffHunk, int, int, error) {
+	if len(old) > MaxDiffUnits || len(new) > MaxDiffUnits {
+		return nil, 0, 0, fmt.Errorf("diff: %d and %d units, at most %d: %w", len(old), len(new), MaxDiffUnits, ErrDiffTooLarge)
+	}
+	ops := diffOps(old, new)
+
+	added, removed := 0, 0
+	for _, op := range ops {
+		switch op.Op {
+		case models.DiffInsert:
+			added++
+		case models.DiffDelete:
+			removed++
+		}
+	}
+	return diffHunks(ops), added, removed, nil
+}
+
+// diffOps computes the edit script through the longest common subsequence of old and new,
+// deleting before inserting where units change.
+func diffOps(old, new []string) []models.DiffLine {
+	var ops []models.DiffLine
+	i, j := 0, 0
+	emit := func(toOld, toNew int) {
+		for ; i < toOld; i++ {
+			ops = append(ops, models.DiffLine{Op: models.DiffDelete, Text: old[i], OldNumber: i + 1})
+		}
+		for ; j < toNew; j++ {
+			ops = append(ops, models.DiffLine{Op: models.DiffInsert, Text: new[j], NewNumber: j + 1})
+		}
+	}
+	for _, match := range lcsMatches(old, new) {
+		emit(match[0], match[1])
+		ops = append(ops, models.DiffLine{Op: models.DiffEqual, Text: old[i], OldNumber: i + 1, NewNumber: j + 1})
+		i++
+		j++
+	}
+	emit(len(old), len(new))
+	return ops
+}
+
+// lcsMatches returns the index pairs of a longest common subsequence of a and b in order. It
+// uses Hirschberg's algorithm, which takes time proportional to len(a)*len(b) but only linear
+// space, after setting aside the common prefix and suffix.
+func lcsMatches(a, b []string) [][2]int {
+	var matches [][2]int
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		matches = append(matches, [2]int{prefix, prefix})
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	matches = hirschberg(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], prefix, prefix, matches)
+	for k := suffix; k > 0; k-- {
+		matches = append(matches, [2]int{len(a) - k, len(b) - k})
+	}
+	return matches
+}
+
+// hirschberg appends the matches of a longest common subsequence of a and b, which start at
+// aOffset and bOffset in the compared sequences, by splitting a in half and b where the halves
+// of a common subsequence meet.
+func hirschberg(a, b []string, aOffset, bOffset int, matches [][2]int) [][2]int {
+	switch {
+	case len(a) == 0 || len(b) == 0:
+		return matches
+	case len(a) == 1:
+		for j := range b {
+			if b[j] == a[0] {
+				return append(matches, [2]int{aOffset, bOffset + j})
+			}
+		}
+		return matches
+	}
+
+	mid := len(a) / 2
+	forward := lcsLengths(mid, len(b), func(i, j int) bool { return a[i] == b[j] })
+	backward := lcsLengths(len(a)-mid, len(b), func(i, j int) bool { return a[len(a)-1-i] == b[len(b)-1-j] })
+
+	split, best := 0, -1
+	for j := 0; j <= len(b); j++ {
+		if length := forward[j] + backward[len(b)-j]; length > best {
+			split, best = j, length
+		}
+	}
+
+	matches = hirschberg(a[:mid], b[:split], aOffset, bOffset, matches)
+	return hirschberg(a[mid:], b[split:], aOffset+mid, bOffset+split, matches)
+}
+
+// lcsLengths returns, for every j up to bLen, the length of a longest common subsequence of the
+// first n units of a and the first j units of b, where equal compares a[i] and b[j]. Only two
+// rows of the table are kept.
+func lcsLengths(n, bLen int, equal func(i, j int) bool) []int {
+	prev, cur := make([]int, bLen+1), make([]int, bLen+1)
+	for i := 0; i < n; i++ {
+		for j := 1; j <= bLen; j++ {
+			if equal(i, j-1) {
+				cur[j] = prev[j-1] + 1
+			} else {
+				cur[j] = max(prev[j], cur[j-1])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev
+}
+
+func diffHunks(ops []models.DiffLine) []models.DiffHunk {
+	var hunks []models.DiffHunk
+
+	for start := 0; start < len(ops); {
+		for start < len(ops) && ops[start].Op == models.DiffEqual {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+
+		from := max(start-diffContext, 0)
+		end := start
+		for end < len(ops) {
+			if ops[end].Op != models.DiffEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].Op == models.DiffEqual {
+				run++
+			}
+			if run == len(ops) || run-end > 2*diffContext {
+				end = min(end+diffContext, len(ops))
+				break
+			}
+			end = run
+		}
+
+		hunks = append(hunks, newHunk(ops[from:end]))
+		start = end
+	}
+	return hunks
+}
+
+func newHunk(lines []models.DiffLine) models.DiffHunk {
+	hunk := models.DiffHunk{Lines: lines}
+	for _, line := range lines {
+		if line.Op != models.DiffInsert {
+			if hunk.OldStart == 0 {
+				hunk.OldStart = line.OldNumber
+			}
+			hunk.OldCount++
+		}
+		if line.Op != models.DiffDelete {
+			if hunk.NewStart == 0 {
+				hunk.NewStart = line.NewNumber
+			}
+			hunk.NewCount++
+		}
+	}
+	return hunk
+}
+
+// UnifiedDiff renders hunks in unified diff format. Verses spanning several lines are
+// prefixed on every line.
+func UnifiedDiff(fromName, toName string, hunks []models.DiffHunk) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+	for _, hunk := range hunks {
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", hunk.OldStart, hunk.OldCount, hunk.NewStart, hunk.NewCount)
+		for _, line := range hunk.Lines {
+			prefix := " "
+			switch line.Op {
+			case models.DiffInsert:
+				prefix = "+"
+			case models.DiffDelete:
+				prefix = "-"
+			}
+			for _, part := range strings.Split(line.Text, "\n") {
+				b.WriteString(prefix + part + "\n")
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"errors"
+	"math/rand/v2"
+	"music-library/internal/models"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDiffUnits(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		granularity string
+		want        []string
+	}{
+		{name: "empty", text: "", granularity: DiffByLine, want: nil},
+		{name: "lines", text: "one\ntwo\n\nthree", granularity: DiffByLine, want: []string{"one", "two", "", "three"}},
+		{name: "verses", text: "one\ntwo\n\nthree", granularity: DiffByVerse, want: []string{"one\ntwo", "three"}},
+		{name: "windows line endings", text: "one\r\ntwo", granularity: DiffByLine, want: []string{"one", "two"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffUnits(tt.text, tt.granularity); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffUnits() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	equal := func(text string, old, new int) models.DiffLine {
+		return models.DiffLine{Op: models.DiffEqual, Text: text, OldNumber: old, NewNumber: new}
+	}
+	insert := func(text string, new int) models.DiffLine {
+		return models.DiffLine{Op: models.DiffInsert, Text: text, NewNumber: new}
+	}
+	remove := func(text string, old int) models.DiffLine {
+		return models.DiffLine{Op: models.DiffDelete, Text: text, OldNumber: old}
+	}
+
+	tests := []struct {
+		name        string
+		old, new    []string
+		wantHunks   []models.DiffHunk
+		wantAdded   int
+		wantRemoved int
+	}{
+		{
+			name: "identical",
+			old:  []string{"a", "b"},
+			new:  []string{"a", "b"},
+		},
+		{
+			name:      "from nothing",
+			new:       []string{"a", "b"},
+			wantHunks: []models.DiffHunk{{OldStart: 0, OldCount: 0, NewStart: 1, NewCount: 2, Lines: []models.DiffLine{insert("a", 1), insert("b", 2)}}},
+			wantAdded: 2,
+		},
+		{
+			name: "changed line deletes before inserting",
+			old:  []string{"a", "b", "c"},
+			new:  []string{"a", "x", "c"},
+			wantHunks: []models.DiffHunk{{OldStart: 1, OldCount: 3, NewStart: 1, NewCount: 3, Lines: []models.DiffLine{
+				equal("a", 1, 1), remove("b", 2), insert("x", 2), equal("c", 3, 3),
+			}}},
+			wantAdded:   1,
+			wantRemoved: 1,
+		},
+		{
+			name: "context is cut to three units",
+			old:  []string{"1", "2", "3", "4", "5", "6", "7", "8", "9"},
+			new:  []string{"1", "2", "3", "4", "x", "5", "6", "7", "8", "9"},
+			wantHunks: []models.DiffHunk{{OldStart: 2, OldCount: 6, NewStart: 2, NewCount: 7, Lines: []models.DiffLine{
+				equal("2", 2, 2), equal("3", 3, 3), equal("4", 4, 4), insert("x", 5),
+				equal("5", 5, 6), equal("6", 6, 7), equal("7", 7, 8),
+			}}},
+			wantAdded: 1,
+		},
+		{
+			name: "distant changes make separate hunks",
+			old:  []string{"a", "1", "2", "3", "4", "5", "6", "7", "b"},
+			new:  []string{"x", "1", "2", "3", "4", "5", "6", "7", "y"},
+			wantHunks: []models.DiffHunk{
+				{OldStart: 1, OldCount: 4, NewStart: 1, NewCount: 4, Lines: []models.DiffLine{
+					remove("a", 1), insert("x", 1), equal("1", 2, 2), equal("2", 3, 3), equal("3", 4, 4),
+				}},
+				{OldStart: 6, OldCount: 4, NewStart: 6, NewCount: 4, Lines: []models.DiffLine{
+					equal("5", 6, 6), equal("6", 7, 7), equal("7", 8, 8), remove("b", 9), insert("y", 9),
+				}},
+			},
+			wantAdded:   2,
+			wantRemoved: 2,
+		},
+		{
+			name: "moved line",
+			old:  []string{"a", "b", "c"},
+			new:  []string{"b", "c", "a"},
+			wantHunks: []models.DiffHunk{{OldStart: 1, OldCount: 3, NewStart: 1, NewCount: 3, Lines: []models.DiffLine{
+				remove("a", 1), equal("b", 2, 1), equal("c", 3, 2), insert("a", 3),
+			}}},
+			wantAdded:   1,
+			wantRemoved: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hunks, added, removed, err := Diff(tt.old, tt.new)
+			if err != nil {
+				t.Fatalf("Diff() error = %v", err)
+			}
+			if !reflect.DeepEqual(hunks, tt.wantHunks) {
+				t.Errorf("Diff() hunks = %+v, want %+v", hunks, tt.wantHunks)
+			}
+			if added != tt.wantAdded || removed != tt.wantRemoved {
+				t.Errorf("Diff() added, removed = %d, %d, want %d, %d", added, removed, tt.wantAdded, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func TestDiffTooLarge(t *testing.T) {
+	large := strings.Split(strings.Repeat("\n", MaxDiffUnits), "\n")
+	if _, _, _, err := Diff(large, []string{"a"}); !errors.Is(err, ErrDiffTooLarge) {
+		t.Errorf("Diff() of %d units error = %v, want ErrDiffTooLarge", len(large), err)
+	}
+	if _, _, _, err := Diff(large[:MaxDiffUnits], large[:MaxDiffUnits]); err != nil {
+		t.Errorf("Diff() of %d units error = %v", MaxDiffUnits, err)
+	}
+}
+
+// TestDiffMatchesLCS checks on random sequences that the edit script keeps a longest common
+// subsequence and rebuilds both sides.
+func TestDiffMatchesLCS(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	random := func() []string {
+		units := make([]string, rng.IntN(30))
+		for i := range units {
+			units[i] = strconv.Itoa(rng.IntN(4))
+		}
+		return units
+	}
+
+	for n := 0; n < 200; n++ {
+		old, new := random(), random()
+		var gotOld, gotNew []string
+		equal := 0
+		for _, op := range diffOps(old, new) {
+			if op.Op != models.DiffInsert {
+				gotOld = append(gotOld, op.Text)
+			}
+			if op.Op != models.DiffDelete {
+				gotNew = append(gotNew, op.Text)
+			}
+			if op.Op == models.DiffEqual {
+				equal++
+			}
+		}
+		if !reflect.DeepEqual(gotOld, nilIfEmpty(old)) || !reflect.DeepEqual(gotNew, nilIfEmpty(new)) {
+			t.Fatalf("diffOps(%q, %q) does not rebuild both sides", old, new)
+		}
+		if want := lcsLengths(len(old), len(new), func(i, j int) bool { return old[i] == new[j] })[len(new)]; equal != want {
+			t.Fatalf("diffOps(%q, %q) keeps %d units, want %d", old, new, equal, want)
+		}
+	}
+}
+
+func nilIfEmpty(units []string) []string {
+	if len(units) == 0 {
+		return nil
+	}
+	return units
+}
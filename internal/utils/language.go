@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"fmt"
+	"golang.org/x/text/language"
+)
+
+// LanguagePreferences returns the languages a client asked for, in order of preference.
+// An explicit lang parameter wins over the Accept-Language header. Invalid header entries are ignored.
+func LanguagePreferences(lang, acceptLanguage string) ([]language.Tag, error) {
+	if lang != "" {
+		tag, err := language.Parse(lang)
+		if err != nil {
+			return nil, fmt.Errorf("invalid language %q: %w", lang, err)
+		}
+		return []language.Tag{tag}, nil
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil {
+		return nil, nil
+	}
+	return tags, nil
+}
+
+// CanonicalLanguage validates a BCP-47 language tag and returns its canonical form, e.g. "en-us" becomes "en-US".
+func CanonicalLanguage(lang string) (string, error) {
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return "", fmt.Errorf("invalid language %q: %w", lang, err)
+	}
+	return tag.String(), nil
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"music-library/internal/models"
+)
+
+type loggerKey struct{}
+
+// ContextWithLogger returns a copy of ctx carrying a request-scoped logger.
+func ContextWithLogger(ctx context.Context, log *logrus.Entry) context.Context {
+	return context.WithValue(ctx, loggerKey{}, log)
+}
+
+// LoggerFromContext returns the request-scoped logger stored in ctx,
+// or an entry of the fallback logger if there is none.
+func LoggerFromContext(ctx context.Context, fallback *logrus.Logger) *logrus.Entry {
+	if log, ok := ctx.Value(loggerKey{}).(*logrus.Entry); ok {
+		return log
+	}
+	return logrus.NewEntry(fallback)
+}
+
+// SongFields describes a song for structured logging. The lyrics are replaced with their length.
+func SongFields(song models.Song) logrus.Fields {
+	fields := logrus.Fields{}
+	if song.ID != nil {
+		fields["song_id"] = *song.ID
+	}
+	if song.Group != nil {
+		fields["group"] = *song.Group
+	}
+	if song.Song != nil {
+		fields["song"] = *song.Song
+	}
+	if song.ReleaseDate != nil {
+		fields["release_date"] = song.ReleaseDate.Format("2006-01-02")
+	}
+	if song.Text != nil {
+		fields["text_length"] = len(*song.Text)
+	}
+	if song.Link != nil {
+		fields["link"] = *song.Link
+	}
+	return fields
+}
+
+// UpdateFields describes a song update for structured logging. The lyrics are replaced with their length.
+func UpdateFields(update models.SongUpdate) logrus.Fields {
+	fields := logrus.Fields{}
+	if update.Group != nil {
+		fields["group"] = *update.Group
+	}
+	if update.Song != nil {
+		fields["song"] = *update.Song
+	}
+	if update.ReleaseDate != nil {
+		fields["release_date"] = update.ReleaseDate.Format("2006-01-02")
+	}
+	if update.Text != nil {
+		fields["text_length"] = len(*update.Text)
+	}
+	if update.Link != nil {
+		fields["link"] = *update.Link
+	}
+	if update.Explicit != nil {
+		fields["explicit"] = *update.Explicit
+	}
+	return fields
+}
+
+type userKey struct{}
+
+// ContextWithUser returns a copy of ctx carrying the id of the user making the request.
+func ContextWithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFromContext returns the id of the user making the request, or nil if it is unknown.
+func UserFromContext(ctx context.Context) *string {
+	if user, ok := ctx.Value(userKey{}).(string); ok && user != "" {
+		return &user
+	}
+	return nil
+}
+
+type primaryKey struct{}
+
+// ContextWithPrimary returns a copy of ctx whose reads go to the primary database, so the
+// request sees its client's own writes.
+func ContextWithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// PrimaryRequired reports whether reads made with ctx must go to the primary database.
+func PrimaryRequired(ctx context.Context) bool {
+	required, _ := ctx.Value(primaryKey{}).(bool)
+	return required
+}
@@ -4,13 +4,23 @@ import (
 	"github.com/sirupsen/logrus"
 	"music-library/config"
 	"os"
+	"strconv"
+	"strings"
 )
 
 func LoadLogger(cfg *config.Config) *logrus.Logger {
 	log := logrus.New()
 
 	log.SetOutput(os.Stdout)
-	log.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	switch strings.ToLower(cfg.LogFormat) {
+	case "json":
+		log.SetFormatter(&logrus.JSONFormatter{})
+	case "text", "":
+		log.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	default:
+		log.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+		log.Warn("Invalid LOG_FORMAT, defaulting to text")
+	}
 
 	level, err := logrus.ParseLevel(cfg.LogLevel)
 	if err != nil {
@@ -19,6 +29,38 @@ func LoadLogger(cfg *config.Config) *logrus.Logger {
 	}
 
 	log.SetLevel(level)
+	log.AddHook(&redactHook{maxLength: cfg.LogMaxFieldLength})
 
 	return log
 }
+
+// redactHook truncates long string fields such as song text so that full lyrics never reach the logs.
+type redactHook struct {
+	maxLength int
+}
+
+func (h *redactHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *redactHook) Fire(entry *logrus.Entry) error {
+	for k, v := range entry.Data {
+		if s, ok := v.(string); ok {
+			entry.Data[k] = Truncate(s, h.maxLength)
+		}
+	}
+	return nil
+}
+
+// Truncate shortens s to at most max runes and notes how long the original value was.
+// A non-positive max disables truncation.
+func Truncate(s string, max int) string {
+	if max <= 0 {
+		return s
+	}
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max]) + "...(" + strconv.Itoa(len(runes)) + " chars)"
+}
@@ -0,0 +1,274 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"music-library/internal/models"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	lrcTimestamp = regexp.MustCompile(`^\[(\d{1,3}):(\d{1,2})(?:[.:](\d{1,3}))?\]`)
+	lrcTag       = regexp.MustCompile(`^\[([A-Za-z#]+):(.*)\]$`)
+	lrcWordTag   = regexp.MustCompile(`<(\d{1,3}):(\d{1,2})(?:[.:](\d{1,3}))?>`)
+)
+
+var ErrLyricsNotSynced = errors.New("lyrics are not time-synced")
+
+// LRCError describes a problem on a single line of an LRC document. Line numbers start at 1.
+type LRCError struct {
+	Line    int    `json:"line" example:"3"`
+	Message string `json:"message" example:"missing timestamp"`
+}
+
+// LRCErrors is returned by ParseLRC and lists every invalid line of the document.
+type LRCErrors []LRCError
+
+func (e LRCErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, lineErr := range e {
+		messages = append(messages, fmt.Sprintf("line %d: %s", lineErr.Line, lineErr.Message))
+	}
+	return "invalid LRC: " + strings.Join(messages, "; ")
+}
+
+type lrcEntry struct {
+	line      models.LyricsLine
+	start     int
+	lineBreak bool
+}
+
+// ParseLRC parses lyrics in LRC or enhanced LRC format. Lines may carry several timestamps,
+// blank lines and lines with an empty text separate sections and the [offset:] tag shifts every
+// timestamp. ID tags such as [ar:] or [ti:] are ignored.
+func ParseLRC(document string) (models.Lyrics, error) {
+	var entries []lrcEntry
+	var errs LRCErrors
+	offset := 0
+	last := 0
+
+	// Trailing blank lines end no section, and would split the repeats of the last lines off.
+	document = strings.TrimRight(strings.ReplaceAll(document, "\r\n", "\n"), " \t\n")
+	for i, raw := range strings.Split(document, "\n") {
+		lineNumber := i + 1
+		line := strings.TrimSpace(raw)
+
+		if line == "" {
+			entries = append(entries, lrcEntry{start: last, lineBreak: true})
+			continue
+		}
+
+		if !lrcTimestamp.MatchString(line) {
+			m := lrcTag.FindStringSubmatch(line)
+			if m == nil {
+				errs = append(errs, LRCError{Line: lineNumber, Message: "missing timestamp"})
+				continue
+			}
+			if strings.EqualFold(m[1], "offset") {
+				value, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(m[2]), "+"))
+				if err != nil {
+					errs = append(errs, LRCError{Line: lineNumber, Message: fmt.Sprintf("invalid offset %q", m[2])})
+					continue
+				}
+				offset = value
+			}
+			continue
+		}
+
+		var starts []int
+		for {
+			m := lrcTimestamp.FindStringSubmatch(line)
+			if m == nil {
+				break
+			}
+			start, err := lrcMillis(m[1], m[2], m[3])
+			if err != nil {
+				errs = append(errs, LRCError{Line: lineNumber, Message: err.Error()})
+			}
+			starts = append(starts, start)
+			line = line[len(m[0]):]
+		}
+
+		text, words, err := lrcWords(line)
+		if err != nil {
+			errs = append(errs, LRCError{Line: lineNumber, Message: err.Error()})
+			continue
+		}
+
+		// The word times are written for the first timestamp, each repeat gets its own copy
+		// shifted to its start.
+		for _, start := range starts {
+			entry := lrcEntry{start: start, lineBreak: text == ""}
+			if !entry.lineBreak {
+				entry.line = models.LyricsLine{Text: text, Words: shiftWords(words, start-starts[0])}
+			}
+			entries = append(entries, entry)
+		}
+		last = starts[0]
+	}
+
+	if len(errs) > 0 {
+		return models.Lyrics{}, errs
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].start < entries[j].start })
+
+	var lyrics models.Lyrics
+	var section models.LyricsSection
+	flush := func() {
+		if len(section.Lines) > 0 {
+			lyrics.Sections = append(lyrics.Sections, section)
+		}
+		section = models.LyricsSection{}
+	}
+	for _, entry := range entries {
+		if entry.lineBreak {
+			flush()
+			continue
+		}
+		start := max(entry.start-offset, 0)
+		entry.line.StartMs = &start
+		for i := range entry.line.Words {
+			entry.line.Words[i].StartMs = max(entry.line.Words[i].StartMs-offset, 0)
+		}
+		section.Lines = append(section.Lines, entry.line)
+	}
+	flush()
+
+	if len(lyrics.Sections) == 0 {
+		return models.Lyrics{}, LRCErrors{{Line: 1, Message: "no timed lines"}}
+	}
+
+	assignKinds(lyrics.Sections, make([]bool, len(lyrics.Sections)))
+	return lyrics, nil
+}
+
+// shiftWords returns a copy of words with their start times moved by deltaMs.
+func shiftWords(words []models.LyricsWord, deltaMs int) []models.LyricsWord {
+	if words == nil {
+		return nil
+	}
+	shifted := make([]models.LyricsWord, len(words))
+	for i, word := range words {
+		shifted[i] = models.LyricsWord{StartMs: word.StartMs + deltaMs, Text: word.Text}
+	}
+	return shifted
+}
+
+// lrcWords splits the text of an enhanced LRC line on its <mm:ss.xx> word timestamps.
+// Plain LRC lines return no words.
+func lrcWords(line string) (string, []models.LyricsWord, error) {
+	matches := lrcWordTag.FindAllStringSubmatchIndex(line, -1)
+	if matches == nil {
+		return strings.TrimSpace(line), nil, nil
+	}
+
+	var words []models.LyricsWord
+	var texts []string
+	if prefix := strings.TrimSpace(line[:matches[0][0]]); prefix != "" {
+		texts = append(texts, prefix)
+	}
+	for i, m := range matches {
+		start, err := lrcMillis(line[m[2]:m[3]], line[m[4]:m[5]], optionalGroup(line, m[6], m[7]))
+		if err != nil {
+			return "", nil, err
+		}
+		end := len(line)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		word := strings.TrimSpace(line[m[1]:end])
+		if word == "" {
+			continue
+		}
+		words = append(words, models.LyricsWord{StartMs: start, Text: word})
+		texts = append(texts, word)
+	}
+	return strings.Join(texts, " "), words, nil
+}
+
+func optionalGroup(s string, start, end int) string {
+	if start < 0 {
+		return ""
+	}
+	return s[start:end]
+}
+
+func lrcMillis(minutes, seconds, fraction string) (int, error) {
+	m, _ := strconv.Atoi(minutes)
+	s, _ := strconv.Atoi(seconds)
+	if s >= 60 {
+		return 0, fmt.Errorf("invalid timestamp %s:%s: seconds out of range", minutes, seconds)
+	}
+
+	ms := 0
+	switch len(fraction) {
+	case 1:
+		ms, _ = strconv.Atoi(fraction)
+		ms *= 100
+	case 2:
+		ms, _ = strconv.Atoi(fraction)
+		ms *= 10
+	case 3:
+		ms, _ = strconv.Atoi(fraction)
+	}
+	return (m*60+s)*1000 + ms, nil
+}
+
+// FormatLRC renders time-synced lyrics as LRC, using enhanced LRC word timestamps for lines that have them.
+func FormatLRC(lyrics models.Lyrics) (string, error) {
+	if !lyrics.Synced() {
+		return "", ErrLyricsNotSynced
+	}
+
+	var b strings.Builder
+	for i, section := range lyrics.Sections {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		for _, line := range section.Lines {
+			b.WriteString("[" + lrcTime(*line.StartMs) + "]")
+			if len(line.Words) == 0 {
+				b.WriteString(line.Text)
+			} else {
+				for _, word := range line.Words {
+					b.WriteString(" <" + lrcTime(word.StartMs) + "> " + word.Text)
+				}
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String(), nil
+}
+
+// lrcTime formats a time in hundredths of a second, as most players expect, unless that would
+// lose milliseconds.
+func lrcTime(ms int) string {
+	if ms%10 != 0 {
+		return fmt.Sprintf("%02d:%02d.%03d", ms/60000, ms/1000%60, ms%1000)
+	}
+	return fmt.Sprintf("%02d:%02d.%02d", ms/60000, ms/1000%60, ms%1000/10)
+}
+
+// ActiveLine returns the last line that starts at or before offsetMs.
+// The boolean is false when the lyrics are not synced or offsetMs precedes the first line.
+func ActiveLine(lyrics models.Lyrics, offsetMs int) (models.ActiveLine, bool) {
+	active := models.ActiveLine{SongID: lyrics.SongID, OffsetMs: offsetMs}
+	found := false
+	for i, section := range lyrics.Sections {
+		for j, line := range section.Lines {
+			if line.StartMs == nil || *line.StartMs > offsetMs {
+				continue
+			}
+			if found && *line.StartMs < *active.Line.StartMs {
+				continue
+			}
+			active.SectionIndex, active.LineIndex, active.Line = i, j, line
+			found = true
+		}
+	}
+	return active, found
+}
@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"music-library/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestParseLRCFormatLRC(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     string
+	}{
+		{
+			name:     "plain lines",
+			document: "[00:01.00]Hey Jude\n[00:04.50]Don't make it bad\n",
+			want:     "[00:01.00]Hey Jude\n[00:04.50]Don't make it bad\n",
+		},
+		{
+			name:     "sections",
+			document: "[00:01.00]One\n\n[00:10.00]Two\n",
+			want:     "[00:01.00]One\n\n[00:10.00]Two\n",
+		},
+		{
+			name:     "milliseconds are kept",
+			document: "[00:01.234]One\n[00:02.5]Two\n",
+			want:     "[00:01.234]One\n[00:02.50]Two\n",
+		},
+		{
+			name:     "word timestamps",
+			document: "[00:10.00] <00:10.00> Hey <00:10.505> Jude\n",
+			want:     "[00:10.00] <00:10.00> Hey <00:10.505> Jude\n",
+		},
+		{
+			name:     "repeated line",
+			document: "[00:10.00][00:50.00]Na na na\n",
+			want:     "[00:10.00]Na na na\n[00:50.00]Na na na\n",
+		},
+		{
+			name:     "repeated line shifts word timestamps",
+			document: "[00:10.00][00:50.00]<00:10.00>Na <00:10.50>na\n",
+			want:     "[00:10.00] <00:10.00> Na <00:10.50> na\n[00:50.00] <00:50.00> Na <00:50.50> na\n",
+		},
+		{
+			name:     "offset applies once to each repeat",
+			document: "[offset:500]\n[00:10.00][00:50.00]<00:10.00>Na <00:10.50>na\n",
+			want:     "[00:09.50] <00:09.50> Na <00:10.00> na\n[00:49.50] <00:49.50> Na <00:50.00> na\n",
+		},
+		{
+			name:     "id tags are ignored",
+			document: "[ar:The Beatles]\n[ti:Hey Jude]\n[01:02.03]Hey Jude\n",
+			want:     "[01:02.03]Hey Jude\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lyrics, err := ParseLRC(tt.document)
+			if err != nil {
+				t.Fatalf("ParseLRC() error = %v", err)
+			}
+			got, err := FormatLRC(lyrics)
+			if err != nil {
+				t.Fatalf("FormatLRC() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("FormatLRC() = %q, want %q", got, tt.want)
+			}
+
+			reparsed, err := ParseLRC(got)
+			if err != nil {
+				t.Fatalf("ParseLRC() of formatted lyrics error = %v", err)
+			}
+			if !reflect.DeepEqual(reparsed, lyrics) {
+				t.Errorf("ParseLRC(FormatLRC()) = %+v, want %+v", reparsed, lyrics)
+			}
+		})
+	}
+}
+
+func TestParseLRCErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     LRCErrors
+	}{
+		{
+			name:     "missing timestamp",
+			document: "[00:01.00]One\nTwo\n",
+			want:     LRCErrors{{Line: 2, Message: "missing timestamp"}},
+		},
+		{
+			name:     "seconds out of range",
+			document: "[00:61.00]One\n",
+			want:     LRCErrors{{Line: 1, Message: "invalid timestamp 00:61: seconds out of range"}},
+		},
+		{
+			name:     "invalid offset",
+			document: "[offset:soon]\n[00:01.00]One\n",
+			want:     LRCErrors{{Line: 1, Message: `invalid offset "soon"`}},
+		},
+		{
+			name:     "no timed lines",
+			document: "[ar:The Beatles]\n",
+			want:     LRCErrors{{Line: 1, Message: "no timed lines"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseLRC(tt.document)
+			got, ok := err.(LRCErrors)
+			if !ok {
+				t.Fatalf("ParseLRC() error = %v, want LRCErrors", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLRC() error = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLRCRepeatedWordsAreNotShared(t *testing.T) {
+	lyrics, err := ParseLRC("[00:10.00][00:50.00]<00:10.00>a\n")
+	if err != nil {
+		t.Fatalf("ParseLRC() error = %v", err)
+	}
+	lines := lyrics.Sections[0].Lines
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	want := []models.LyricsWord{{StartMs: 10000, Text: "a"}, {StartMs: 50000, Text: "a"}}
+	for i, line := range lines {
+		if !reflect.DeepEqual(line.Words, want[i:i+1]) {
+			t.Errorf("line %d words = %+v, want %+v", i, line.Words, want[i:i+1])
+		}
+	}
+	lines[0].Words[0].StartMs = 0
+	if lines[1].Words[0].StartMs != 50000 {
+		t.Errorf("changing the words of a line changed its repeat")
+	}
+}
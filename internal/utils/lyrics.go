@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"music-library/internal/models"
+	"regexp"
+	"strings"
+)
+
+var (
+	sectionSeparator = regexp.MustCompile(`\n[ \t]*\n+`)
+	sectionMarker    = regexp.MustCompile(`(?i)^[\[(]?\s*(intro|verse|pre-chorus|chorus|refrain|hook|bridge|outro)(\s*\d+)?\s*[\])]?\s*:?$`)
+)
+
+var markerKinds = map[string]models.SectionKind{
+	"intro":      models.SectionIntro,
+	"verse":      models.SectionVerse,
+	"pre-chorus": models.SectionVerse,
+	"chorus":     models.SectionChorus,
+	"refrain":    models.SectionChorus,
+	"hook":       models.SectionChorus,
+	"bridge":     models.SectionBridge,
+	"outro":      models.SectionOutro,
+}
+
+// NormalizeLyrics converts escaped "\n" sequences and CRLF line endings to plain newlines,
+// strips trailing whitespace from every line and trims surrounding blank lines.
+func NormalizeLyrics(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, `\n`, "\n")
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// ParseLyrics splits text into sections separated by blank lines. A section may start with a
+// marker line such as "[Chorus]" or "Verse 2:", which sets its kind and is dropped from the lines.
+// A marker on its own applies to the following section. Unmarked sections that occur more than
+// once are treated as a chorus, everything else as a verse.
+func ParseLyrics(text string) models.Lyrics {
+	var lyrics models.Lyrics
+
+	normalized := NormalizeLyrics(text)
+	if normalized == "" {
+		return lyrics
+	}
+
+	var marked []bool
+	var pending models.SectionKind
+	for _, block := range sectionSeparator.Split(normalized, -1) {
+		lines := strings.Split(block, "\n")
+
+		kind := pending
+		pending = ""
+		if m := sectionMarker.FindStringSubmatch(strings.TrimSpace(lines[0])); m != nil {
+			kind = markerKinds[strings.ToLower(m[1])]
+			lines = lines[1:]
+		}
+		if len(lines) == 0 {
+			pending = kind
+			continue
+		}
+
+		section := models.LyricsSection{Kind: kind}
+		for _, line := range lines {
+			section.Lines = append(section.Lines, models.LyricsLine{Text: line})
+		}
+		lyrics.Sections = append(lyrics.Sections, section)
+		marked = append(marked, kind != "")
+	}
+
+	assignKinds(lyrics.Sections, marked)
+	return lyrics
+}
+
+// assignKinds sets the kind of every section not marked explicitly: sections that occur
+// more than once are a chorus, everything else is a verse.
+func assignKinds(sections []models.LyricsSection, marked []bool) {
+	counts := make(map[string]int)
+	for _, section := range sections {
+		counts[strings.ToLower(section.Text())]++
+	}
+	for i := range sections {
+		if marked[i] {
+			continue
+		}
+		if counts[strings.ToLower(sections[i].Text())] > 1 {
+			sections[i].Kind = models.SectionChorus
+		} else {
+			sections[i].Kind = models.SectionVerse
+		}
+	}
+}
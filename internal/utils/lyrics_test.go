@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"music-library/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestParseLyrics(t *testing.T) {
+	section := func(kind models.SectionKind, lines ...string) models.LyricsSection {
+		s := models.LyricsSection{Kind: kind}
+		for _, line := range lines {
+			s.Lines = append(s.Lines, models.LyricsLine{Text: line})
+		}
+		return s
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want []models.LyricsSection
+	}{
+		{name: "empty", text: "\n \n", want: nil},
+		{
+			name: "unmarked sections are verses",
+			text: "One\nTwo\n\nThree",
+			want: []models.LyricsSection{section(models.SectionVerse, "One", "Two"), section(models.SectionVerse, "Three")},
+		},
+		{
+			name: "repeated sections are a chorus",
+			text: "First\n\nNa na\nHey\n\nSecond\n\nna NA\nhey",
+			want: []models.LyricsSection{
+				section(models.SectionVerse, "First"),
+				section(models.SectionChorus, "Na na", "Hey"),
+				section(models.SectionVerse, "Second"),
+				section(models.SectionChorus, "na NA", "hey"),
+			},
+		},
+		{
+			name: "markers set the kind and are dropped",
+			text: "[Chorus]\nLa la\n\nVerse 2:\nWords\n\n(Pre-Chorus)\nRising\n\nHOOK\nYeah\n\n[Outro]\nBye",
+			want: []models.LyricsSection{
+				section(models.SectionChorus, "La la"),
+				section(models.SectionVerse, "Words"),
+				section(models.SectionVerse, "Rising"),
+				section(models.SectionChorus, "Yeah"),
+				section(models.SectionOutro, "Bye"),
+			},
+		},
+		{
+			name: "marker on its own applies to the next section",
+			text: "[Bridge]\n\nOver the bridge",
+			want: []models.LyricsSection{section(models.SectionBridge, "Over the bridge")},
+		},
+		{
+			name: "marked sections keep their kind when repeated",
+			text: "[Intro]\nOh\n\nOh",
+			want: []models.LyricsSection{section(models.SectionIntro, "Oh"), section(models.SectionChorus, "Oh")},
+		},
+		{
+			name: "lines merely starting with a marker word are lyrics",
+			text: "Chorus of birds\nsinging",
+			want: []models.LyricsSection{section(models.SectionVerse, "Chorus of birds", "singing")},
+		},
+		{
+			name: "escaped newlines and windows line endings",
+			text: `One\n\nTwo` + "\r\n\r\nThree  ",
+			want: []models.LyricsSection{
+				section(models.SectionVerse, "One"),
+				section(models.SectionVerse, "Two"),
+				section(models.SectionVerse, "Three"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseLyrics(tt.text).Sections; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLyrics() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,12 +1,17 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
 	"github.com/sirupsen/logrus"
-	"strings"
 )
 
-func PaginateVerses(text string, page, limit int, log *logrus.Logger) ([]string, error) {
+var ErrPageOutOfRange = errors.New("page is out of range")
+
+// PaginateVerses returns the given page of verses, see models.Lyrics.Verses.
+// It accepts any verse representation, such as plain strings or aligned verses. The first page of
+// no verses is empty, any page past the verses is ErrPageOutOfRange.
+func PaginateVerses[T any](verses []T, page, limit int, log logrus.FieldLogger) ([]T, error) {
 	log.Infof("PaginateVerses called with page: %d, limit: %d", page, limit)
 
 	if page < 1 {
@@ -18,15 +23,18 @@ func PaginateVerses(text string, page, limit int, log *logrus.Logger) ([]string,
 		log.Warn("pagination: Limit is less than 1, defaulting to 10")
 	}
 
-	verses := strings.Split(text, "\\n\\n")
 	log.Infof("Total verses found: %d", len(verses))
 
 	start := (page - 1) * limit
 	end := start + limit
 	log.Debugf("Calculated start index: %d, end index: %d", start, end)
 
+	if len(verses) == 0 && page == 1 {
+		log.Info("Returning no verses for page: 1")
+		return []T{}, nil
+	}
 	if start >= len(verses) {
-		err := fmt.Errorf("pagination: Page number is out of range")
+		err := fmt.Errorf("pagination: page %d: %w", page, ErrPageOutOfRange)
 		log.Errorf("Error: %v", err)
 		return nil, err
 	}
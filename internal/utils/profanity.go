@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/text/language"
+	"io/fs"
+	"music-library/internal/models"
+	"music-library/profanity"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// ProfanityFilter flags explicit lyrics using per-language word lists. Lists are text files named
+// after their language, e.g. en.txt, holding a term per line. Blank lines and lines starting with
+// "#" are ignored and a term ending in "*" matches every word starting with it.
+type ProfanityFilter struct {
+	lists     map[language.Base]wordList
+	threshold int
+}
+
+type wordList struct {
+	words    map[string]bool
+	prefixes []string
+}
+
+// LoadProfanityFilter reads the word lists in dir, or the lists built into the binary when dir is
+// empty. A directory without lists is an error, so that explicit content is never left unflagged
+// by mistake. Lyrics with at least threshold matched terms are explicit.
+func LoadProfanityFilter(dir string, threshold int, log *logrus.Logger) (*ProfanityFilter, error) {
+	lists, source := fs.FS(profanity.FS), "built-in lists"
+	if dir != "" {
+		lists, source = os.DirFS(dir), dir
+	}
+
+	paths, err := fs.Glob(lists, "*.txt")
+	if err != nil {
+		return nil, fmt.Errorf("profanity: list %s: %w", source, err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("profanity: no word lists named <language>.txt in %s", source)
+	}
+
+	filter := &ProfanityFilter{lists: make(map[language.Base]wordList), threshold: threshold}
+	for _, path := range paths {
+		name := strings.TrimSuffix(path, ".txt")
+		tag, err := language.Parse(name)
+		if err != nil {
+			return nil, fmt.Errorf("profanity: %s: invalid language %q: %w", path, name, err)
+		}
+		base, _ := tag.Base()
+
+		list, err := readWordList(lists, path)
+		if err != nil {
+			return nil, err
+		}
+		existing, ok := filter.lists[base]
+		if ok {
+			for word := range list.words {
+				existing.words[word] = true
+			}
+			existing.prefixes = append(existing.prefixes, list.prefixes...)
+			list = existing
+		}
+		filter.lists[base] = list
+		log.Infof("Loaded %d profanity terms for %s from %s in %s", len(list.words)+len(list.prefixes), base, path, source)
+	}
+	return filter, nil
+}
+
+func readWordList(lists fs.FS, path string) (wordList, error) {
+	file, err := lists.Open(path)
+	if err != nil {
+		return wordList{}, fmt.Errorf("profanity: open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	list := wordList{words: make(map[string]bool)}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		term := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if term == "" || strings.HasPrefix(term, "#") {
+			continue
+		}
+		if prefix, ok := strings.CutSuffix(term, "*"); ok {
+			list.prefixes = append(list.prefixes, prefix)
+		} else {
+			list.words[term] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return wordList{}, fmt.Errorf("profanity: read %s: %w", path, err)
+	}
+	return list, nil
+}
+
+// Classify counts the words of text matching the lists of lang, or every list when the
+// language is undetermined, and flags the text as explicit once the count reaches the threshold.
+func (f *ProfanityFilter) Classify(text, lang string) models.ContentFlags {
+	lists := f.listsFor(lang)
+	count := 0
+	for _, word := range wordPattern.FindAllString(text, -1) {
+		if matchesAny(lists, strings.ToLower(word)) {
+			count++
+		}
+	}
+	return models.ContentFlags{Explicit: count >= f.threshold, ProfanityCount: count}
+}
+
+// Censor masks every character of the words of text matching the lists of lang with "*".
+func (f *ProfanityFilter) Censor(text, lang string) string {
+	lists := f.listsFor(lang)
+	return wordPattern.ReplaceAllStringFunc(text, func(word string) string {
+		if !matchesAny(lists, strings.ToLower(word)) {
+			return word
+		}
+		return strings.Repeat("*", utf8.RuneCountInString(word))
+	})
+}
+
+func (f *ProfanityFilter) listsFor(lang string) []wordList {
+	tag, err := language.Parse(lang)
+	if err == nil && lang != models.LanguageUndetermined {
+		base, _ := tag.Base()
+		if list, ok := f.lists[base]; ok {
+			return []wordList{list}
+		}
+		return nil
+	}
+
+	lists := make([]wordList, 0, len(f.lists))
+	for _, list := range f.lists {
+		lists = append(lists, list)
+	}
+	return lists
+}
+
+func matchesAny(lists []wordList, word string) bool {
+	for _, list := range lists {
+		if list.words[word] {
+			return true
+		}
+		for _, prefix := range list.prefixes {
+			if strings.HasPrefix(word, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"github.com/sirupsen/logrus"
+	"io"
+	"music-library/internal/models"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testProfanityFilter(t *testing.T, threshold int) *ProfanityFilter {
+	t.Helper()
+	dir := t.TempDir()
+	lists := map[string]string{
+		"en.txt":    "# English\ndarn\nheck*\n",
+		"en-GB.txt": "Bloody\n",
+		"ru.txt":    "блин*\n",
+	}
+	for name, terms := range lists {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(terms), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	filter, err := LoadProfanityFilter(dir, threshold, quietLogger())
+	if err != nil {
+		t.Fatalf("LoadProfanityFilter() error = %v", err)
+	}
+	return filter
+}
+
+func quietLogger() *logrus.Logger {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	return log
+}
+
+func TestProfanityFilterClassify(t *testing.T) {
+	filter := testProfanityFilter(t, 2)
+
+	tests := []struct {
+		name string
+		text string
+		lang string
+		want models.ContentFlags
+	}{
+		{name: "clean", text: "Hello darkness", lang: "en", want: models.ContentFlags{}},
+		{name: "below threshold", text: "Oh darn it", lang: "en", want: models.ContentFlags{ProfanityCount: 1}},
+		{name: "at threshold", text: "Darn, what the HECKING mess", lang: "en", want: models.ContentFlags{Explicit: true, ProfanityCount: 2}},
+		{name: "wildcard needs the whole prefix", text: "he hecks heck", lang: "en", want: models.ContentFlags{Explicit: true, ProfanityCount: 2}},
+		{name: "lists of a language are merged", text: "bloody darn", lang: "en-US", want: models.ContentFlags{Explicit: true, ProfanityCount: 2}},
+		{name: "other languages do not count", text: "darn блины", lang: "ru", want: models.ContentFlags{ProfanityCount: 1}},
+		{name: "undetermined uses every list", text: "darn блины", lang: models.LanguageUndetermined, want: models.ContentFlags{Explicit: true, ProfanityCount: 2}},
+		{name: "language without a list", text: "darn heck", lang: "fr", want: models.ContentFlags{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filter.Classify(tt.text, tt.lang); got != tt.want {
+				t.Errorf("Classify(%q, %q) = %+v, want %+v", tt.text, tt.lang, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfanityFilterCensor(t *testing.T) {
+	filter := testProfanityFilter(t, 1)
+
+	tests := []struct {
+		name string
+		text string
+		lang string
+		want string
+	}{
+		{name: "whole word", text: "Oh darn it", lang: "en", want: "Oh **** it"},
+		{name: "wildcard match is masked whole", text: "Heckin' good, heckler!", lang: "en", want: "******' good, *******!"},
+		{name: "characters not bytes", text: "Ну блин, блинчик", lang: "ru", want: "Ну ****, *******"},
+		{name: "only the lists of the language", text: "darn блин", lang: "ru", want: "darn ****"},
+		{name: "words containing a term are kept", text: "sunbloody", lang: "en", want: "sunbloody"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filter.Censor(tt.text, tt.lang); got != tt.want {
+				t.Errorf("Censor(%q, %q) = %q, want %q", tt.text, tt.lang, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadProfanityFilter(t *testing.T) {
+	if _, err := LoadProfanityFilter("", 1, quietLogger()); err != nil {
+		t.Errorf("LoadProfanityFilter() of the built-in lists error = %v", err)
+	}
+	if _, err := LoadProfanityFilter(t.TempDir(), 1, quietLogger()); err == nil {
+		t.Error("LoadProfanityFilter() of a directory without lists succeeded, want an error")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "not a language.txt"), []byte("darn\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadProfanityFilter(dir, 1, quietLogger()); err == nil {
+		t.Error("LoadProfanityFilter() of a list not named after a language succeeded, want an error")
+	}
+}
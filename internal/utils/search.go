@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"music-library/internal/models"
+	"slices"
+	"unicode"
+)
+
+// FindMatches returns the case-insensitive, non-overlapping occurrences of phrase in text.
+// Offsets count characters rather than bytes.
+func FindMatches(text, phrase string) []models.MatchOffset {
+	haystack, needle := foldRunes(text), foldRunes(phrase)
+	if len(needle) == 0 {
+		return nil
+	}
+
+	var matches []models.MatchOffset
+	for i := 0; i+len(needle) <= len(haystack); {
+		if slices.Equal(haystack[i:i+len(needle)], needle) {
+			matches = append(matches, models.MatchOffset{Start: i, End: i + len(needle)})
+			i += len(needle)
+			continue
+		}
+		i++
+	}
+	return matches
+}
+
+// SearchVerses returns the verses of a song containing phrase, see models.Lyrics.Verses. Page
+// is computed for pages of pageSize verses as served by PaginateVerses.
+func SearchVerses(songID int, verses []string, phrase string, pageSize int) []models.VerseMatch {
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	matches := []models.VerseMatch{}
+	for i, verse := range verses {
+		offsets := FindMatches(verse, phrase)
+		if len(offsets) == 0 {
+			continue
+		}
+		matches = append(matches, models.VerseMatch{
+			SongID:  songID,
+			Index:   i,
+			Page:    i/pageSize + 1,
+			Text:    verse,
+			Matches: offsets,
+		})
+	}
+	return matches
+}
+
+// foldRunes lowercases s rune by rune so that offsets into the result are offsets into s.
+func foldRunes(s string) []rune {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
+}
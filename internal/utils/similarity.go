@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"cmp"
+	"math"
+	"music-library/internal/models"
+	"slices"
+	"strings"
+)
+
+// Weights of the parts of a similarity score, adding up to 1.
+const (
+	lyricsWeight = 0.4
+	tagsWeight   = 0.3
+	groupWeight  = 0.2
+	yearWeight   = 0.1
+)
+
+// closeYears is the gap in years from which release years no longer count as close.
+const closeYears = 10
+
+type posting struct {
+	song   int
+	weight float64
+}
+
+// SimilarityIndex compares songs by group, tags, release year and lyrics. Lyrics are compared as
+// TF-IDF vectors of their words, leaving out the stopwords of every known language. The songs of
+// each group, tag and lyrics term are listed, so that a song is only compared with the songs
+// related to it.
+type SimilarityIndex struct {
+	songs    []models.SongFeatures
+	byID     map[int]int
+	tags     []map[string]bool
+	vectors  []map[string]float64
+	postings map[string][]posting
+	byGroup  map[int][]int
+	byTag    map[string][]int
+}
+
+// NewSimilarityIndex indexes songs, the whole library, which the document frequencies of the
+// TF-IDF weights are computed over.
+func NewSimilarityIndex(songs []models.SongFeatures) *SimilarityIndex {
+	ix := &SimilarityIndex{
+		songs:    songs,
+		byID:     make(map[int]int, len(songs)),
+		tags:     make([]map[string]bool, len(songs)),
+		vectors:  make([]map[string]float64, len(songs)),
+		postings: map[string][]posting{},
+		byGroup:  map[int][]int{},
+		byTag:    map[string][]int{},
+	}
+
+	stop := stopwordSet(models.LanguageUndetermined)
+	counts := make([]map[string]int, len(songs))
+	df := map[string]int{}
+	for i, song := range songs {
+		ix.byID[song.ID] = i
+		ix.byGroup[song.GroupID] = append(ix.byGroup[song.GroupID], i)
+		ix.tags[i] = make(map[string]bool, len(song.Tags))
+		for _, tag := range song.Tags {
+			if !ix.tags[i][tag] {
+				ix.tags[i][tag] = true
+				ix.byTag[tag] = append(ix.byTag[tag], i)
+			}
+		}
+
+		tf := map[string]int{}
+		for _, word := range wordPattern.FindAllString(strings.ToLower(song.Text), -1) {
+			if !stop[word] {
+				tf[word]++
+			}
+		}
+		for term := range tf {
+			df[term]++
+		}
+		counts[i] = tf
+	}
+
+	// Term frequencies are dampened logarithmically and the inverse document frequency is
+	// smoothed, so that a term found in every song still counts a little.
+	n := float64(len(songs))
+	for i, tf := range counts {
+		vector := make(map[string]float64, len(tf))
+		var norm float64
+		for term, count := range tf {
+			weight := (1 + math.Log(float64(count))) * (math.Log((1+n)/(1+float64(df[term]))) + 1)
+			vector[term] = weight
+			norm += weight * weight
+		}
+		norm = math.Sqrt(norm)
+		for term, weight := range vector {
+			vector[term] = weight / norm
+			ix.postings[term] = append(ix.postings[term], posting{song: i, weight: weight / norm})
+		}
+		ix.vectors[i] = vector
+	}
+	return ix
+}
+
+// Similar returns up to top of the songs most similar to the song with the given id, the most
+// similar first, with only their ids set. A song is only related to another when they share a
+// group, a tag or a lyrics term, so close release years alone do not make songs similar.
+func (ix *SimilarityIndex) Similar(id, top int) []models.SimilarSong {
+	i, ok := ix.byID[id]
+	if !ok {
+		return nil
+	}
+
+	// The related songs are gathered from the lists of the song's lyrics terms, group and tags,
+	// summing the cosine of the lyrics and counting the shared tags on the way.
+	cosine := map[int]float64{}
+	for term, weight := range ix.vectors[i] {
+		for _, p := range ix.postings[term] {
+			cosine[p.song] += weight * p.weight
+		}
+	}
+	sharedTags := map[int]int{}
+	for tag := range ix.tags[i] {
+		for _, j := range ix.byTag[tag] {
+			sharedTags[j]++
+		}
+	}
+	song := ix.songs[i]
+	related := make(map[int]bool, len(cosine)+len(sharedTags))
+	for j := range cosine {
+		related[j] = true
+	}
+	for j := range sharedTags {
+		related[j] = true
+	}
+	for _, j := range ix.byGroup[song.GroupID] {
+		related[j] = true
+	}
+	delete(related, i)
+
+	var similar []models.SimilarSong
+	for j := range related {
+		other := ix.songs[j]
+		shared := sharedTags[j]
+		sameGroup := other.GroupID == song.GroupID
+		lyrics := math.Min(cosine[j], 1)
+
+		result := models.SimilarSong{
+			SongID:           other.ID,
+			SameGroup:        sameGroup,
+			SharedTags:       shared,
+			LyricsSimilarity: lyrics,
+		}
+		score := lyricsWeight * lyrics
+		if shared > 0 {
+			score += tagsWeight * float64(shared) / float64(len(ix.tags[i])+len(ix.tags[j])-shared)
+		}
+		if sameGroup {
+			score += groupWeight
+		}
+		if knownYear(song.ReleaseDate.Year()) && knownYear(other.ReleaseDate.Year()) {
+			gap := song.ReleaseDate.Year() - other.ReleaseDate.Year()
+			if gap < 0 {
+				gap = -gap
+			}
+			result.YearGap = &gap
+			if gap < closeYears {
+				score += yearWeight * float64(closeYears-gap) / closeYears
+			}
+		}
+		result.Score = score
+		similar = append(similar, result)
+	}
+
+	slices.SortFunc(similar, func(a, b models.SimilarSong) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(a.SongID, b.SongID))
+	})
+	if len(similar) > top {
+		similar = similar[:top]
+	}
+	return similar
+}
+
+// knownYear reports whether a release year is set. Songs without a release date are stored
+// with 0001-01-01.
+func knownYear(year int) bool {
+	return year > 1
+}
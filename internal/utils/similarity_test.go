@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"math"
+	"music-library/internal/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSimilar(t *testing.T) {
+	year := func(y int) time.Time { return time.Date(y, time.January, 1, 0, 0, 0, 0, time.UTC) }
+	gap := func(g int) *int { return &g }
+
+	index := NewSimilarityIndex([]models.SongFeatures{
+		{ID: 1, GroupID: 1, ReleaseDate: year(1990), Text: "Sunshine after the rain", Tags: []string{"rock", "pop"}},
+		{ID: 2, GroupID: 1, ReleaseDate: year(1992), Tags: []string{"rock"}},
+		{ID: 3, GroupID: 2, ReleaseDate: year(2005), Tags: []string{"pop", "jazz"}},
+		{ID: 4, GroupID: 3, Text: "sunshine after the RAIN"},
+		{ID: 5, GroupID: 4, ReleaseDate: year(1990), Text: "thunder", Tags: []string{"metal"}},
+	})
+
+	tests := []struct {
+		name string
+		id   int
+		top  int
+		want []models.SimilarSong
+	}{
+		{
+			name: "weights, year cutoff and unrelated songs",
+			id:   1,
+			top:  10,
+			want: []models.SimilarSong{
+				// 0.2 for the group, 0.3 * 1/2 for the tags and 0.1 * 8/10 for the years.
+				{SongID: 2, Score: 0.43, SameGroup: true, SharedTags: 1, YearGap: gap(2)},
+				// 0.4 for the same lyrics, with no release year to compare.
+				{SongID: 4, Score: 0.4, LyricsSimilarity: 1},
+				// 0.3 * 1/3 for the tags, the years being too far apart to count.
+				{SongID: 3, Score: 0.1, SharedTags: 1, YearGap: gap(15)},
+			},
+		},
+		{
+			name: "top",
+			id:   1,
+			top:  1,
+			want: []models.SimilarSong{
+				{SongID: 2, Score: 0.43, SameGroup: true, SharedTags: 1, YearGap: gap(2)},
+			},
+		},
+		{
+			name: "empty lyrics",
+			id:   2,
+			top:  10,
+			want: []models.SimilarSong{
+				{SongID: 1, Score: 0.43, SameGroup: true, SharedTags: 1, YearGap: gap(2)},
+			},
+		},
+		{
+			name: "nothing related",
+			id:   5,
+			top:  10,
+		},
+		{
+			name: "unknown song",
+			id:   6,
+			top:  10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := index.Similar(tt.id, tt.top)
+			for i := range got {
+				got[i].Score = roundScore(got[i].Score)
+				got[i].LyricsSimilarity = roundScore(got[i].LyricsSimilarity)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Similar(%d, %d) = %+v, want %+v", tt.id, tt.top, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSimilarLyrics(t *testing.T) {
+	index := NewSimilarityIndex([]models.SongFeatures{
+		{ID: 1, GroupID: 1, Text: "love song of the night"},
+		{ID: 2, GroupID: 2, Text: "the night"},
+		{ID: 3, GroupID: 3, Text: "love song"},
+		{ID: 4, GroupID: 4, Text: "the and of"},
+	})
+
+	similar := index.Similar(1, 10)
+	if len(similar) != 2 {
+		t.Fatalf("Similar(1) = %+v, want songs 2 and 3", similar)
+	}
+	for _, song := range similar {
+		if song.LyricsSimilarity <= 0 || song.LyricsSimilarity >= 1 || math.IsNaN(song.Score) {
+			t.Errorf("Similar(1) song %d lyrics similarity = %v, want between 0 and 1", song.SongID, song.LyricsSimilarity)
+		}
+	}
+	// Two of the three words are shared with song 3 and one with song 2.
+	if similar[0].SongID != 3 {
+		t.Errorf("Similar(1) first = song %d, want song 3", similar[0].SongID)
+	}
+	// Lyrics made only of stopwords have no terms to compare.
+	if got := index.Similar(4, 10); got != nil {
+		t.Errorf("Similar(4) = %+v, want none", got)
+	}
+}
+
+func roundScore(score float64) float64 {
+	return math.Round(score*1e9) / 1e9
+}
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"cmp"
+	"math"
+	"music-library/internal/models"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// readingWordsPerMinute is the reading speed used to estimate reading time.
+const readingWordsPerMinute = 200
+
+var wordPattern = regexp.MustCompile(`[\p{L}\p{N}]+(?:['’][\p{L}\p{N}]+)*`)
+
+// stopwords are the words left out of the most frequent terms, by base language.
+var stopwords = map[string][]string{
+	"en": strings.Fields(`a about all am an and any are as at be been but by can could did do does don't
+		for from had has have he her him his how i i'm if in into is it it's its just let me my no not now of
+		on or our out she so than that the their them then there they this to too up us was we were what when
+		where which who will with would you you're your`),
+	"ru": strings.Fields(`а без бы был была были было в вам вас во вот все всё вы где да для до его ее её
+		если есть ж же за и из или им их к как когда кто ли меня мне мой мы на над не него нет ни но ну о об
+		он она они оно от по под при с со так там те тебя тебе то ты у уж уже что чтобы это я`),
+}
+
+// LyricsStats computes word and line statistics of lyrics. Words are compared ignoring case and
+// topTerms limits the most frequent terms, which leave out the stopwords of the lyrics language
+// or of every known language when it is undetermined.
+func LyricsStats(lyrics models.Lyrics, topTerms int) models.SongStats {
+	stats := models.SongStats{
+		SongID:        lyrics.SongID,
+		Language:      lyrics.Language,
+		Verses:        len(lyrics.Sections),
+		RepeatedLines: []models.RepeatedLine{},
+		TopTerms:      []models.TermCount{},
+	}
+
+	stop := stopwordSet(lyrics.Language)
+	words := map[string]int{}
+	terms := map[string]int{}
+	lines := map[string]int{}
+	var lineOrder []string
+
+	for _, section := range lyrics.Sections {
+		if section.Kind == models.SectionChorus {
+			stats.ChorusSections++
+		}
+		for _, line := range section.Lines {
+			text := strings.TrimSpace(line.Text)
+			if text == "" {
+				continue
+			}
+			stats.Lines++
+
+			key := strings.ToLower(text)
+			if lines[key] == 0 {
+				lineOrder = append(lineOrder, text)
+			}
+			lines[key]++
+
+			for _, word := range wordPattern.FindAllString(key, -1) {
+				stats.Words++
+				words[word]++
+				if !stop[word] {
+					terms[word]++
+				}
+			}
+		}
+	}
+
+	stats.UniqueWords = len(words)
+	stats.ReadingTimeSeconds = int(math.Ceil(float64(stats.Words) * 60 / readingWordsPerMinute))
+
+	for _, line := range lineOrder {
+		if count := lines[strings.ToLower(line)]; count > 1 {
+			stats.RepeatedLines = append(stats.RepeatedLines, models.RepeatedLine{Text: line, Count: count})
+		}
+	}
+	slices.SortStableFunc(stats.RepeatedLines, func(a, b models.RepeatedLine) int {
+		return cmp.Compare(b.Count, a.Count)
+	})
+
+	for term, count := range terms {
+		stats.TopTerms = append(stats.TopTerms, models.TermCount{Term: term, Count: count})
+	}
+	slices.SortFunc(stats.TopTerms, func(a, b models.TermCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Term, b.Term))
+	})
+	if len(stats.TopTerms) > topTerms {
+		stats.TopTerms = stats.TopTerms[:topTerms]
+	}
+
+	return stats
+}
+
+func stopwordSet(lang string) map[string]bool {
+	base, _, _ := strings.Cut(lang, "-")
+	set := map[string]bool{}
+	for language, words := range stopwords {
+		if base == language || lang == models.LanguageUndetermined {
+			for _, word := range words {
+				set[word] = true
+			}
+		}
+	}
+	return set
+}
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxTagLength is the longest tag name, in characters.
+const MaxTagLength = 64
+
+// NormalizeTag returns the canonical form of a tag name: lower case with single spaces. A tag must
+// contain a letter or digit and, since the library filter takes tags as a comma separated list,
+// no commas.
+func NormalizeTag(name string) (string, error) {
+	tag := strings.ToLower(strings.Join(strings.Fields(name), " "))
+	if tag == "" {
+		return "", fmt.Errorf("empty tag")
+	}
+	if utf8.RuneCountInString(tag) > MaxTagLength {
+		return "", fmt.Errorf("tag %q is longer than %d characters", tag, MaxTagLength)
+	}
+	if strings.ContainsRune(tag, ',') {
+		return "", fmt.Errorf("tag %q contains a comma", tag)
+	}
+	if !strings.ContainsFunc(tag, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsNumber(r) }) {
+		return "", fmt.Errorf("tag %q has no letters or digits", tag)
+	}
+	return tag, nil
+}
+
+// ParseTags normalizes a list of tags, each value holding one or more comma separated tags,
+// and returns them sorted without duplicates.
+func ParseTags(values []string) ([]string, error) {
+	var tags []string
+	for _, value := range values {
+		for _, name := range strings.Split(value, ",") {
+			tag, err := NormalizeTag(name)
+			if err != nil {
+				return nil, err
+			}
+			tags = append(tags, tag)
+		}
+	}
+	slices.Sort(tags)
+	return slices.Compact(tags), nil
+}
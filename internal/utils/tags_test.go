@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	tests := []struct {
+		name    string
+		tag     string
+		want    string
+		wantErr bool
+	}{
+		{name: "lower case", tag: "Rock", want: "rock"},
+		{name: "single spaces", tag: "  Hip \t Hop ", want: "hip hop"},
+		{name: "digits", tag: "80s", want: "80s"},
+		{name: "other scripts", tag: "Шансон", want: "шансон"},
+		{name: "punctuation with letters", tag: "r&b", want: "r&b"},
+		{name: "longest", tag: strings.Repeat("é", MaxTagLength), want: strings.Repeat("é", MaxTagLength)},
+		{name: "empty", tag: " \t", wantErr: true},
+		{name: "comma", tag: "rock,pop", wantErr: true},
+		{name: "no letters or digits", tag: "- & -", wantErr: true},
+		{name: "too long", tag: strings.Repeat("a", MaxTagLength+1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeTag(tt.tag)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeTag(%q) error = %v, wantErr %t", tt.tag, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeTag(%q) = %q, want %q", tt.tag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "none", values: nil, want: nil},
+		{name: "comma separated", values: []string{"Rock, pop"}, want: []string{"pop", "rock"}},
+		{name: "several values", values: []string{"rock", "Jazz,blues"}, want: []string{"blues", "jazz", "rock"}},
+		{name: "duplicates", values: []string{"Rock,rock", " ROCK "}, want: []string{"rock"}},
+		{name: "empty tag", values: []string{"rock,,pop"}, wantErr: true},
+		{name: "trailing comma", values: []string{"rock,"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTags(tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTags(%q) error = %v, wantErr %t", tt.values, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTags(%q) = %q, want %q", tt.values, got, tt.want)
+			}
+		})
+	}
+}
@@ -1 +1 @@
-TRUNCATE TABLE songs, groups RESTART IDENTITY CASCADE;
+-- Nothing to undo, see the up migration. Rolling back no longer truncates the library.
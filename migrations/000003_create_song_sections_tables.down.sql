@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS song_lines CASCADE;
+
+DROP TABLE IF EXISTS song_sections CASCADE;
+
+UPDATE songs SET text = replace(text, chr(10), '\n') WHERE position(chr(10) IN text) > 0;
@@ -0,0 +1,21 @@
+CREATE TABLE song_sections
+(
+    id       SERIAL PRIMARY KEY,
+    song_id  INT NOT NULL,
+    position INT NOT NULL,
+    kind     VARCHAR(16) NOT NULL,
+    FOREIGN KEY (song_id) REFERENCES songs (id) ON DELETE CASCADE,
+    UNIQUE (song_id, position)
+);
+
+CREATE TABLE song_lines
+(
+    id         SERIAL PRIMARY KEY,
+    section_id INT NOT NULL,
+    position   INT NOT NULL,
+    text       TEXT NOT NULL,
+    FOREIGN KEY (section_id) REFERENCES song_sections (id) ON DELETE CASCADE,
+    UNIQUE (section_id, position)
+);
+
+UPDATE songs SET text = replace(text, '\n', chr(10)) WHERE position('\n' IN text) > 0;
@@ -0,0 +1,3 @@
+ALTER TABLE song_lines
+    DROP COLUMN IF EXISTS words,
+    DROP COLUMN IF EXISTS start_ms;
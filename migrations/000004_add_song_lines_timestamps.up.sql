@@ -0,0 +1,3 @@
+ALTER TABLE song_lines
+    ADD COLUMN start_ms INT,
+    ADD COLUMN words    JSONB;
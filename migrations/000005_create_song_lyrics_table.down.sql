@@ -0,0 +1,11 @@
+DELETE FROM song_sections AS s
+USING song_lyrics AS l
+WHERE l.song_id = s.song_id AND l.language = s.language AND NOT l.is_original;
+
+ALTER TABLE song_sections
+    DROP CONSTRAINT IF EXISTS song_sections_song_id_language_fkey,
+    DROP CONSTRAINT IF EXISTS song_sections_song_id_language_position_key,
+    DROP COLUMN IF EXISTS language,
+    ADD CONSTRAINT song_sections_song_id_position_key UNIQUE (song_id, position);
+
+DROP TABLE IF EXISTS song_lyrics CASCADE;
@@ -0,0 +1,21 @@
+CREATE TABLE song_lyrics
+(
+    song_id     INT NOT NULL,
+    language    VARCHAR(35) NOT NULL,
+    is_original BOOLEAN NOT NULL DEFAULT FALSE,
+    text        TEXT NOT NULL,
+    PRIMARY KEY (song_id, language),
+    FOREIGN KEY (song_id) REFERENCES songs (id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX idx_song_lyrics_original ON song_lyrics (song_id) WHERE is_original;
+
+INSERT INTO song_lyrics (song_id, language, is_original, text)
+SELECT id, 'und', TRUE, text
+FROM songs;
+
+ALTER TABLE song_sections
+    ADD COLUMN language VARCHAR(35) NOT NULL DEFAULT 'und',
+    DROP CONSTRAINT song_sections_song_id_position_key,
+    ADD CONSTRAINT song_sections_song_id_language_position_key UNIQUE (song_id, language, position),
+    ADD CONSTRAINT song_sections_song_id_language_fkey FOREIGN KEY (song_id, language)
+        REFERENCES song_lyrics (song_id, language) ON DELETE CASCADE ON UPDATE CASCADE;
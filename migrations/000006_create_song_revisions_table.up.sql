@@ -0,0 +1,14 @@
+CREATE TABLE song_revisions
+(
+    song_id    INT NOT NULL,
+    revision   INT NOT NULL,
+    text       TEXT NOT NULL,
+    author     VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (song_id, revision),
+    FOREIGN KEY (song_id) REFERENCES songs (id) ON DELETE CASCADE
+);
+
+INSERT INTO song_revisions (song_id, revision, text)
+SELECT id, 1, text
+FROM songs;
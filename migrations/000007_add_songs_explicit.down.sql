@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_songs_explicit;
+
+ALTER TABLE songs
+    DROP COLUMN profanity_count,
+    DROP COLUMN explicit;
@@ -0,0 +1,5 @@
+ALTER TABLE songs
+    ADD COLUMN explicit        BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN profanity_count INT     NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_songs_explicit ON songs (explicit);
@@ -0,0 +1 @@
+DROP TABLE idempotency_keys;
@@ -0,0 +1,11 @@
+CREATE TABLE idempotency_keys
+(
+    key           VARCHAR(255) PRIMARY KEY,
+    request_hash  CHAR(64)     NOT NULL,
+    status_code   INT          NOT NULL,
+    content_type  VARCHAR(255) NOT NULL,
+    response_body BYTEA        NOT NULL,
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    expires_at    TIMESTAMPTZ  NOT NULL
+);
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
@@ -0,0 +1,16 @@
+CREATE TABLE tags
+(
+    id   SERIAL PRIMARY KEY,
+    name VARCHAR(64) NOT NULL UNIQUE
+);
+
+CREATE TABLE song_tags
+(
+    song_id INT NOT NULL,
+    tag_id  INT NOT NULL,
+    PRIMARY KEY (song_id, tag_id),
+    FOREIGN KEY (song_id) REFERENCES songs (id) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tags (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_song_tags_tag_id ON song_tags (tag_id);
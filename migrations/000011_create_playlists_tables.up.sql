@@ -0,0 +1,30 @@
+CREATE TABLE playlists
+(
+    id          SERIAL PRIMARY KEY,
+    owner       VARCHAR(255) NOT NULL,
+    name        VARCHAR(255) NOT NULL,
+    description TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_playlists_owner ON playlists (owner);
+
+-- Positions run from 1 without gaps. The unique check is deferred so that moving items can shift
+-- their neighbours one statement at a time. A deleted song leaves its items behind with a NULL
+-- song_id and its names copied into group_name and song_name.
+CREATE TABLE playlist_items
+(
+    id          SERIAL PRIMARY KEY,
+    playlist_id INT NOT NULL,
+    position    INT NOT NULL,
+    song_id     INT,
+    group_name  VARCHAR(255),
+    song_name   VARCHAR(255),
+    added_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (playlist_id, position) DEFERRABLE INITIALLY DEFERRED,
+    FOREIGN KEY (playlist_id) REFERENCES playlists (id) ON DELETE CASCADE,
+    FOREIGN KEY (song_id) REFERENCES songs (id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_playlist_items_song_id ON playlist_items (song_id);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS song_daily_plays;
+DROP TABLE IF EXISTS song_popularity;
+DROP TABLE IF EXISTS song_plays;
+DROP TABLE IF EXISTS favorites;
@@ -0,0 +1,45 @@
+CREATE TABLE favorites
+(
+    user_id    VARCHAR(255) NOT NULL,
+    song_id    INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, song_id),
+    FOREIGN KEY (song_id) REFERENCES songs (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_favorites_song_id ON favorites (song_id);
+
+-- Raw play events, kept for listening histories. Rankings read the rollups below, which are
+-- updated in the same transaction as each event.
+CREATE TABLE song_plays
+(
+    id        BIGSERIAL PRIMARY KEY,
+    song_id   INT NOT NULL,
+    user_id   VARCHAR(255),
+    played_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    FOREIGN KEY (song_id) REFERENCES songs (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_song_plays_song_id ON song_plays (song_id);
+CREATE INDEX idx_song_plays_user_id_played_at ON song_plays (user_id, played_at DESC) WHERE user_id IS NOT NULL;
+
+CREATE TABLE song_popularity
+(
+    song_id   INT PRIMARY KEY,
+    plays     BIGINT NOT NULL DEFAULT 0,
+    favorites INT NOT NULL DEFAULT 0,
+    FOREIGN KEY (song_id) REFERENCES songs (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_song_popularity_plays ON song_popularity (plays DESC, song_id);
+
+CREATE TABLE song_daily_plays
+(
+    song_id INT NOT NULL,
+    day     DATE NOT NULL,
+    plays   BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (song_id, day),
+    FOREIGN KEY (song_id) REFERENCES songs (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_song_daily_plays_day ON song_daily_plays (day);
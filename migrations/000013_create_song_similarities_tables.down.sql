@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS song_similarity_queue;
+DROP TABLE IF EXISTS song_similarities;
@@ -0,0 +1,31 @@
+-- The songs most similar to each song, refreshed by a background job. year_gap is NULL when a
+-- release date is unknown.
+CREATE TABLE song_similarities
+(
+    song_id           INT NOT NULL,
+    similar_id        INT NOT NULL,
+    score             REAL NOT NULL,
+    same_group        BOOLEAN NOT NULL,
+    shared_tags       INT NOT NULL,
+    year_gap          INT,
+    lyrics_similarity REAL NOT NULL,
+    PRIMARY KEY (song_id, similar_id),
+    FOREIGN KEY (song_id) REFERENCES songs (id) ON DELETE CASCADE,
+    FOREIGN KEY (similar_id) REFERENCES songs (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_song_similarities_song_id_score ON song_similarities (song_id, score DESC);
+CREATE INDEX idx_song_similarities_similar_id ON song_similarities (similar_id);
+
+-- Songs whose similarities must be recomputed. A song queued again while being processed gets a
+-- new queued_at, so that it is processed once more.
+CREATE TABLE song_similarity_queue
+(
+    song_id   INT PRIMARY KEY,
+    queued_at TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp(),
+    FOREIGN KEY (song_id) REFERENCES songs (id) ON DELETE CASCADE
+);
+
+INSERT INTO song_similarity_queue (song_id)
+SELECT id
+FROM songs;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_songs_song_name_trgm;
+DROP INDEX IF EXISTS idx_groups_group_name_trgm;
+DROP FUNCTION IF EXISTS fold_text(TEXT);
+-- The extensions may be used elsewhere in the database, so they are kept.
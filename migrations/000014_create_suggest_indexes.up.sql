@@ -0,0 +1,11 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+-- fold_text folds case and diacritics for matching names as typed. unaccent is only stable, as
+-- its dictionary could change, so it is wrapped with the dictionary named to be indexable.
+CREATE FUNCTION fold_text(value TEXT) RETURNS TEXT
+    LANGUAGE sql IMMUTABLE STRICT PARALLEL SAFE
+    AS $$ SELECT lower(public.unaccent('public.unaccent'::regdictionary, value)) $$;
+
+CREATE INDEX idx_groups_group_name_trgm ON groups USING gin (fold_text(group_name) gin_trgm_ops);
+CREATE INDEX idx_songs_song_name_trgm ON songs USING gin (fold_text(song_name) gin_trgm_ops);
@@ -0,0 +1,6 @@
+DELETE FROM idempotency_keys WHERE status_code IS NULL;
+
+ALTER TABLE idempotency_keys
+    ALTER COLUMN status_code SET NOT NULL,
+    ALTER COLUMN content_type SET NOT NULL,
+    ALTER COLUMN response_body SET NOT NULL;
@@ -0,0 +1,6 @@
+-- A key without a status code is claimed by a request still being handled. Its expires_at is the
+-- end of the claim, after which the key can be claimed again.
+ALTER TABLE idempotency_keys
+    ALTER COLUMN status_code DROP NOT NULL,
+    ALTER COLUMN content_type DROP NOT NULL,
+    ALTER COLUMN response_body DROP NOT NULL;
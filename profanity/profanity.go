@@ -0,0 +1,8 @@
+// Package profanity embeds the default profanity word lists so the binary can flag explicit
+// lyrics from any directory.
+package profanity
+
+import "embed"
+
+//go:embed *.txt
+var FS embed.FS